@@ -0,0 +1,68 @@
+package main
+
+import (
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// autopairs maps the characters that get a closing partner to it.
+var autopairs = map[rune]rune{
+	'(': ')',
+	'[': ']',
+	'`': '`',
+	'"': '"',
+}
+
+// autopair handles a typed character that opens or closes a pair, reporting
+// whether it did. An opening character gets its closing partner inserted
+// after the cursor, and a closing character typed just before the same one
+// moves over it rather than doubling it up.
+func (m *model) autopair(msg tea.KeyMsg) bool {
+	if msg.Type != tea.KeyRunes || len(msg.Runes) != 1 || msg.Alt {
+		return false
+	}
+
+	r := msg.Runes[0]
+	line := currentLine(m.input)
+	col := cursorColumn(m.input)
+	if col > len(line) {
+		col = len(line)
+	}
+	var prev, next rune
+	if col > 0 {
+		prev = line[col-1]
+	}
+	if col < len(line) {
+		next = line[col]
+	}
+
+	if next == r && isCloser(r) {
+		m.input.SetCursor(col + 1)
+		return true
+	}
+
+	closer, ok := autopairs[r]
+	if !ok || next != 0 && !unicode.IsSpace(next) && !isCloser(next) {
+		return false
+	}
+	// Quotes straight after a word close it rather than open a new one,
+	// and a third backtick starts a code fence.
+	if r == closer && (unicode.IsLetter(prev) || unicode.IsDigit(prev) || prev == r) {
+		return false
+	}
+
+	m.input.InsertString(string(r) + string(closer))
+	m.input.SetCursor(col + 1)
+	return true
+}
+
+// isCloser reports whether r closes one of the autopairs.
+func isCloser(r rune) bool {
+	for _, closer := range autopairs {
+		if r == closer {
+			return true
+		}
+	}
+	return false
+}
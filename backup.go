@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// backupPath is the name of the nth most recent backup of path, counting
+// from zero.
+func backupPath(path string, n int) string {
+	if n == 0 {
+		return path + ".bak"
+	}
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
+
+// backupFile copies path to path.bak before it's overwritten, first moving
+// older backups along to .bak.1, .bak.2 and so on so that at most keep of
+// them are left. There's nothing to back up if path doesn't exist yet.
+func backupFile(path string, keep int, mode os.FileMode) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := os.Remove(backupPath(path, keep-1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for n := keep - 2; n >= 0; n-- {
+		err := os.Rename(backupPath(path, n), backupPath(path, n+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return os.WriteFile(backupPath(path, 0), content, mode)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	tabStyle       = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Padding(0, 1)
+	activeTabStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("230")).Background(lipgloss.Color("57")).Padding(0, 1)
+)
+
+// buffer is the state of one open file. The active buffer's state lives on
+// model itself while it's being edited, and is only copied back here when
+// switching to another one.
+type buffer struct {
+	input          textarea.Model
+	filePath       string
+	title          string
+	metadata       orderedMap
+	metadataEdited bool
+	timeSpent      time.Duration
+	savedContent   string
+	dirty          bool
+	modTime        time.Time
+	externalChange bool
+	undoStack      []snapshot
+	redoStack      []snapshot
+	lastEdit       time.Time
+	lineEnding     string
+	bom            bool
+	folds          []fold
+}
+
+// scratchTitle is the title of the scratch buffer.
+const scratchTitle = "[scratch]"
+
+// stashBuffer copies the active buffer's state off the model.
+func (m *model) stashBuffer() {
+	m.buffers[m.active] = m.currentBuffer()
+}
+
+// currentBuffer returns the state of the buffer being edited.
+func (m model) currentBuffer() buffer {
+	return buffer{
+		input:          m.input,
+		filePath:       m.filePath,
+		title:          m.title,
+		metadata:       m.metadata,
+		metadataEdited: m.metadataEdited,
+		timeSpent:      m.timeSpent,
+		savedContent:   m.savedContent,
+		dirty:          m.dirty,
+		modTime:        m.modTime,
+		externalChange: m.externalChange,
+		undoStack:      m.undoStack,
+		redoStack:      m.redoStack,
+		lastEdit:       m.lastEdit,
+		lineEnding:     m.lineEnding,
+		bom:            m.bom,
+		folds:          m.folds,
+	}
+}
+
+// restoreBuffer makes buffer i the active one.
+func (m *model) restoreBuffer(i int) {
+	m.active = i
+	m.useBuffer(m.buffers[i])
+}
+
+// useBuffer puts b's state on the model to be edited.
+func (m *model) useBuffer(b buffer) {
+	m.input = b.input
+	m.filePath = b.filePath
+	m.title = b.title
+	m.metadata = b.metadata
+	m.metadataEdited = b.metadataEdited
+	m.timeSpent = b.timeSpent
+	m.savedContent = b.savedContent
+	m.dirty = b.dirty
+	m.modTime = b.modTime
+	m.externalChange = b.externalChange
+	m.undoStack = b.undoStack
+	m.redoStack = b.redoStack
+	m.lastEdit = b.lastEdit
+	m.lineEnding = b.lineEnding
+	m.bom = b.bom
+	m.folds = b.folds
+
+	// Search results point into the buffer we just left.
+	m.searchQuery = ""
+	m.clearMatches()
+}
+
+// switchBuffer moves by delta through the open buffers, wrapping around at
+// either end.
+func (m *model) switchBuffer(delta int) {
+	if len(m.buffers) < 2 {
+		return
+	}
+	if m.scratchActive {
+		m.toggleScratch()
+	}
+
+	m.stashBuffer()
+	m.restoreBuffer((m.active + delta + len(m.buffers)) % len(m.buffers))
+	m.sizeInputs()
+	m.renderPreview()
+	if !m.readOnly {
+		m.scrollToCursor()
+	}
+}
+
+// toggleScratch swaps the active buffer for the scratch buffer, or back
+// again. The scratch buffer isn't tied to a file and can't be saved, but
+// keeps what's written in it for the rest of the session.
+func (m *model) toggleScratch() tea.Cmd {
+	if m.scratchActive {
+		m.scratch = m.currentBuffer()
+		m.scratchActive = false
+		m.restoreBuffer(m.active)
+	} else {
+		if m.scratch.title == "" {
+			m.scratch = buffer{input: newTextarea(), title: scratchTitle}
+		}
+		m.stashBuffer()
+		m.scratchActive = true
+		m.useBuffer(m.scratch)
+	}
+
+	m.updateKeybindings()
+	m.sizeInputs()
+	m.renderPreview()
+	if m.readOnly {
+		return nil
+	}
+	return m.scrollToCursor()
+}
+
+// anyDirty reports whether any open buffer has unsaved changes. The
+// scratch buffer doesn't count.
+func (m model) anyDirty() bool {
+	if m.dirty && !m.scratchActive {
+		return true
+	}
+	for i, b := range m.buffers {
+		if (i != m.active || m.scratchActive) && b.dirty {
+			return true
+		}
+	}
+	return false
+}
+
+// tabsHeight is the number of lines the tab strip takes up, which is none
+// when there's only one buffer.
+func (m model) tabsHeight() int {
+	if len(m.buffers) < 2 {
+		return 0
+	}
+	return 1
+}
+
+func (m model) tabsView() string {
+	if len(m.buffers) < 2 {
+		return ""
+	}
+
+	tabs := make([]string, len(m.buffers))
+	for i, b := range m.buffers {
+		// The active buffer's stashed copy is out of date.
+		if i == m.active {
+			b = buffer{filePath: m.filePath, title: m.title, dirty: m.dirty}
+		}
+
+		name := b.title
+		if b.filePath != "" {
+			name = filepath.Base(b.filePath)
+		}
+		if b.dirty {
+			name = "● " + name
+		}
+
+		if i == m.active {
+			tabs[i] = activeTabStyle.Render(name)
+		} else {
+			tabs[i] = tabStyle.Render(name)
+		}
+	}
+	return strings.Join(tabs, " ")
+}
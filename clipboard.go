@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// copyMarkdown copies the document's markdown to the system clipboard.
+func (m *model) copyMarkdown() tea.Cmd {
+	return m.copyToClipboard(m.input.Value())
+}
+
+// copyHTML copies the document to the system clipboard rendered as HTML,
+// without the surrounding page so it can be pasted into another one.
+func (m *model) copyHTML() tea.Cmd {
+	html, err := markdownToHTML(m.input.Value())
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	return m.copyToClipboard(html)
+}
+
+func (m *model) copyToClipboard(s string) tea.Cmd {
+	if err := clipboard.WriteAll(s); err != nil {
+		return m.setStatus("Could not copy to the clipboard: "+err.Error(), true)
+	}
+	return m.setStatus(fmt.Sprintf("Copied %s chars", formatCount(countChars(s))), false)
+}
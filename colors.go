@@ -0,0 +1,46 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	defaultAccentColor    = "212"
+	defaultBorderColor    = "238"
+	defaultHighlightColor = "57"
+)
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// validColor reports whether c is a color lipgloss understands: an ANSI
+// color number or a hex color.
+func validColor(c string) bool {
+	if n, err := strconv.Atoi(c); err == nil {
+		return n >= 0 && n <= 255
+	}
+	return hexColor.MatchString(c)
+}
+
+// setColors restyles the interface with the accent color for the cursor and
+// other highlights, the border color for the focused pane, and the
+// highlight color behind the cursor line and the active tab. It's called
+// before the model is made, since the textarea copies its styles.
+func setColors(accent, border, highlight string) {
+	accentColor := lipgloss.Color(accent)
+	cursorStyle = cursorStyle.Foreground(accentColor)
+	dirtyStyle = dirtyStyle.Foreground(accentColor)
+	menuSelectedStyle = menuSelectedStyle.Foreground(accentColor)
+	fuzzyMatchStyle = fuzzyMatchStyle.Foreground(accentColor)
+	previewCursorBar = lipgloss.NewStyle().Foreground(accentColor).Render("▌")
+
+	borderColor := lipgloss.Color(border)
+	focusedBorderStyle = focusedBorderStyle.BorderForeground(borderColor)
+	rulerStyle = rulerStyle.Foreground(borderColor)
+
+	highlightColor := lipgloss.Color(highlight)
+	cursorLineStyle = cursorLineStyle.Background(highlightColor)
+	activeTabStyle = activeTabStyle.Background(highlightColor)
+}
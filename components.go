@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// component is a markdown snippet that can be inserted from the insert menu.
+// The cursor is left between before and after once it has been inserted.
+type component struct {
+	name   string
+	before string
+	after  string
+
+	// block components start on a line of their own.
+	block bool
+
+	// prompt components ask for link text and a URL before inserting, and
+	// are formatted with format instead of before/after. pick components
+	// have an image file picked for the URL first.
+	prompt bool
+	format string
+	pick   bool
+}
+
+var components = []component{
+	{name: "Link", prompt: true, format: "[%s](%s)"},
+	{name: "Image", prompt: true, format: "![%s](%s)"},
+	{name: "Image from file", prompt: true, format: "![%s](%s)", pick: true},
+	{name: "Code block", before: "```\n", after: "\n```", block: true},
+	{name: "Table", before: "| Column | Column |\n| ------ | ------ |\n| ", after: " | Cell |", block: true},
+	boldMarkers,
+	italicMarkers,
+	{name: "Blockquote", before: "> ", block: true},
+	{name: "Horizontal rule", before: "---\n", block: true},
+}
+
+// Inline formatting inserted straight from a key binding rather than the
+// menu. The textarea has no selection to wrap, so the markers are inserted
+// with the cursor left between them.
+var (
+	boldMarkers   = component{name: "Bold", before: "**", after: "**"}
+	italicMarkers = component{name: "Italic", before: "*", after: "*"}
+	codeMarkers   = component{name: "Code", before: "`", after: "`"}
+)
+
+var (
+	menuStyle = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("99")).
+			Padding(0, 1)
+
+	menuSelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("212"))
+)
+
+func newPromptInput(placeholder string) textinput.Model {
+	t := textinput.New()
+	t.Placeholder = placeholder
+	t.Prompt = ""
+	return t
+}
+
+// openInsertMenu switches into the insert menu with the first entry selected.
+func (m *model) openInsertMenu() {
+	m.mode = insertMenuMode
+	m.menuCursor = 0
+}
+
+func (m model) updateInsertMenu(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = editMode
+		return m, m.input.Focus()
+	case "up", "k":
+		if m.menuCursor > 0 {
+			m.menuCursor--
+		}
+	case "down", "j":
+		if m.menuCursor < len(components)-1 {
+			m.menuCursor++
+		}
+	case "enter":
+		c := components[m.menuCursor]
+		if c.pick {
+			return m, m.openImagePicker()
+		}
+		if !c.prompt {
+			m.checkpoint()
+			m.insertComponent(c)
+			m.mode = editMode
+			return m, m.input.Focus()
+		}
+
+		m.promptInputs = []textinput.Model{
+			newPromptInput("text"),
+			newPromptInput("https://"),
+		}
+		m.promptFocus = 0
+		m.mode = componentPromptMode
+		return m, m.promptInputs[0].Focus()
+	}
+
+	return m, nil
+}
+
+func (m model) updateComponentPrompt(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = editMode
+		return m, m.input.Focus()
+	case "tab", "shift+tab", "enter":
+		if msg.String() == "enter" && m.promptFocus == len(m.promptInputs)-1 {
+			c := components[m.menuCursor]
+			text, url := m.promptInputs[0].Value(), m.promptInputs[1].Value()
+			m.checkpoint()
+			m.input.InsertString(fmt.Sprintf(c.format, text, url))
+			m.mode = editMode
+			return m, m.input.Focus()
+		}
+
+		m.promptInputs[m.promptFocus].Blur()
+		m.promptFocus = (m.promptFocus + 1) % len(m.promptInputs)
+		return m, m.promptInputs[m.promptFocus].Focus()
+	}
+
+	var cmd tea.Cmd
+	m.promptInputs[m.promptFocus], cmd = m.promptInputs[m.promptFocus].Update(msg)
+	return m, cmd
+}
+
+// insertComponent inserts c at the cursor, leaving the cursor between its
+// opening and closing halves.
+func (m *model) insertComponent(c component) {
+	if c.block && cursorColumn(m.input) > 0 {
+		m.input.InsertString("\n")
+	}
+
+	m.input.InsertString(c.before)
+	if c.after == "" {
+		return
+	}
+
+	col := cursorColumn(m.input)
+	m.input.InsertString(c.after)
+	for i := 0; i < strings.Count(c.after, "\n"); i++ {
+		m.input.CursorUp()
+	}
+	m.input.SetCursor(col)
+}
+
+func (m model) insertMenuView() string {
+	b := strings.Builder{}
+
+	if m.mode == componentPromptMode {
+		b.WriteString(components[m.menuCursor].name + "\n\n")
+		labels := []string{"Text", "URL "}
+		for i, in := range m.promptInputs {
+			fmt.Fprintf(&b, "%s  %s\n", labels[i], in.View())
+		}
+		b.WriteString("\nenter confirm • esc cancel")
+		return menuStyle.Render(b.String())
+	}
+
+	b.WriteString("Insert component\n\n")
+	for i, c := range components {
+		if i == m.menuCursor {
+			b.WriteString(menuSelectedStyle.Render("> "+c.name) + "\n")
+		} else {
+			b.WriteString("  " + c.name + "\n")
+		}
+	}
+	b.WriteString("\nenter insert • esc cancel")
+	return menuStyle.Render(b.String())
+}
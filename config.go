@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// config holds the settings read from the config file.
+type config struct {
+	// keys maps action names to the keys that trigger them, replacing the
+	// default keys for those actions.
+	keys map[string][]string
+}
+
+// configPath is where the config file is looked for, following the XDG base
+// directory convention on every platform.
+func configPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "markaway", "config.toml"), nil
+}
+
+// loadConfig reads the config file at path. A missing file isn't an error,
+// it just means the defaults are used.
+//
+// Only the small part of TOML the config needs is understood: comments, a
+// [keys] table, and values that are strings or arrays of strings, e.g.
+//
+//	[keys]
+//	insert_component = "alt+i"
+//	quit = ["esc", "ctrl+q"]
+func loadConfig(path string) (config, error) {
+	cfg := config{keys: map[string][]string{}}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+
+	actions := keyBindings(&keymap{})
+	table := ""
+	for n, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if table != "keys" {
+				return cfg, fmt.Errorf("%s:%d: unknown table [%s]", path, n+1, table)
+			}
+			continue
+		}
+
+		i := strings.Index(line, "=")
+		if i < 0 || table != "keys" {
+			return cfg, fmt.Errorf("%s:%d: expected a key binding in [keys]", path, n+1)
+		}
+
+		action := strings.TrimSpace(line[:i])
+		if _, ok := actions[action]; !ok {
+			return cfg, fmt.Errorf("%s:%d: unknown action %q", path, n+1, action)
+		}
+
+		keys, err := parseConfigStrings(strings.TrimSpace(line[i+1:]))
+		if err != nil {
+			return cfg, fmt.Errorf("%s:%d: %v", path, n+1, err)
+		}
+		cfg.keys[action] = keys
+	}
+
+	return cfg, nil
+}
+
+// parseConfigStrings parses a quoted string or an array of them.
+func parseConfigStrings(v string) ([]string, error) {
+	if !strings.HasPrefix(v, "[") {
+		s, err := strconv.Unquote(v)
+		if err != nil {
+			return nil, fmt.Errorf("expected a quoted string, got %s", v)
+		}
+		return []string{s}, nil
+	}
+
+	if !strings.HasSuffix(v, "]") {
+		return nil, fmt.Errorf("unterminated array %s", v)
+	}
+
+	var values []string
+	for _, item := range strings.Split(v[1:len(v)-1], ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		s, err := strconv.Unquote(item)
+		if err != nil {
+			return nil, fmt.Errorf("expected a quoted string, got %s", item)
+		}
+		values = append(values, s)
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no keys given")
+	}
+	return values, nil
+}
+
+// keyBindings maps the action names used in the config file to the bindings
+// in km.
+func keyBindings(km *keymap) map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"quit":             &km.quit,
+		"next_pane":        &km.next,
+		"prev_pane":        &km.prev,
+		"save":             &km.save,
+		"save_and_quit":    &km.saveAndQuit,
+		"insert_component": &km.insertComponent,
+		"toggle_preview":   &km.togglePreview,
+		"toggle_layout":    &km.toggleLayout,
+		"rename":           &km.rename,
+		"front_matter":     &km.metadata,
+		"undo":             &km.undo,
+		"redo":             &km.redo,
+		"toc":              &km.toc,
+		"outline":          &km.outline,
+		"lint":             &km.lint,
+		"check_links":      &km.checkLinks,
+		"export_html":      &km.exportHTML,
+		"export_text":      &km.exportText,
+		"export_pdf":       &km.exportPDF,
+		"open_browser":     &km.openBrowser,
+		"copy_markdown":    &km.copyMarkdown,
+		"copy_html":        &km.copyHTML,
+		"shrink_editor":    &km.shrinkEditor,
+		"grow_editor":      &km.growEditor,
+		"search":           &km.search,
+		"toggle_wrap":      &km.toggleWrap,
+		"palette":          &km.palette,
+		"help":             &km.showHelp,
+		"toggle_help_bar":  &km.toggleHelpBar,
+		"next_file":        &km.nextBuffer,
+		"prev_file":        &km.prevBuffer,
+		"scratch":          &km.toggleScratch,
+		"insert_date":      &km.insertDate,
+		"bold":             &km.bold,
+		"italic":           &km.italic,
+		"code":             &km.code,
+		"paste_link":       &km.pasteLink,
+		"toggle_task":      &km.toggleTask,
+		"format_table":     &km.formatTable,
+		"promote_heading":  &km.promoteHeading,
+		"demote_heading":   &km.demoteHeading,
+		"toggle_fold":      &km.toggleFold,
+		"insert_date_time": &km.insertDateTime,
+		"zen":              &km.zen,
+		"pause_timer":      &km.pauseTimer,
+	}
+}
+
+// bindKeys replaces the keys of the actions in keys, keeping each binding's
+// description. The first key is the one shown in help.
+func bindKeys(km *keymap, keys map[string][]string) {
+	bindings := keyBindings(km)
+	for action, k := range keys {
+		b := bindings[action]
+		*b = key.NewBinding(
+			key.WithKeys(k...),
+			key.WithHelp(k[0], b.Help().Desc),
+		)
+	}
+}
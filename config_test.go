@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigStrings(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []string
+		wantErr bool
+	}{
+		{in: `"alt+i"`, want: []string{"alt+i"}},
+		{in: `["esc", "ctrl+q"]`, want: []string{"esc", "ctrl+q"}},
+		{in: `["esc",]`, want: []string{"esc"}},
+		{in: `"a\"b"`, want: []string{`a"b`}},
+		{in: `alt+i`, wantErr: true},
+		{in: `["esc"`, wantErr: true},
+		{in: `[esc]`, wantErr: true},
+		{in: `[]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseConfigStrings(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseConfigStrings(%s) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseConfigStrings(%s) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			name:    "bindings",
+			content: "# keys\n[keys]\nquit = [\"esc\", \"ctrl+q\"]\n\nsave = \"ctrl+s\"\n",
+			want:    map[string][]string{"quit": {"esc", "ctrl+q"}, "save": {"ctrl+s"}},
+		},
+		{
+			name:    "unknown table",
+			content: "[colors]\n",
+			wantErr: "unknown table [colors]",
+		},
+		{
+			name:    "unknown action",
+			content: "[keys]\nfly = \"f\"\n",
+			wantErr: `unknown action "fly"`,
+		},
+		{
+			name:    "outside a table",
+			content: "quit = \"esc\"\n",
+			wantErr: "expected a key binding in [keys]",
+		},
+		{
+			name:    "bad value",
+			content: "[keys]\nquit = esc\n",
+			wantErr: "expected a quoted string",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := loadConfig(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cfg.keys, tt.want) {
+				t.Errorf("loadConfig() keys = %q, want %q", cfg.keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "config.toml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.keys) != 0 {
+		t.Errorf("loadConfig() keys = %q, want none", cfg.keys)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxDiffCells bounds the work diffLines does on the lines that differ.
+// Beyond it lines are matched up regardless of order, which is cheaper but
+// can undercount lines that were moved.
+const maxDiffCells = 1 << 20
+
+var (
+	addedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+	removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
+
+// diffLines counts the lines added and removed going from old to new, as
+// a line based diff would.
+func diffLines(old, new string) (added, removed int) {
+	if old == new {
+		return 0, 0
+	}
+	a, b := splitLines(old), splitLines(new)
+
+	// Edits are usually in one place, so trim the lines both share at
+	// either end before diffing what's left.
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		a, b = a[1:], b[1:]
+	}
+	for len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[len(b)-1] {
+		a, b = a[:len(a)-1], b[:len(b)-1]
+	}
+
+	common := 0
+	if len(a)*len(b) <= maxDiffCells {
+		common = longestCommon(a, b)
+	} else {
+		counts := make(map[string]int, len(a))
+		for _, line := range a {
+			counts[line]++
+		}
+		for _, line := range b {
+			if counts[line] > 0 {
+				counts[line]--
+				common++
+			}
+		}
+	}
+	return len(b) - common, len(a) - common
+}
+
+// splitLines splits s into lines, with an empty s having none.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// longestCommon returns the length of the longest common subsequence of a
+// and b.
+func longestCommon(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] > cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// diffView summarises the lines changed since the last save, e.g. "+12 -3",
+// or is empty when there are none.
+func (m model) diffView() string {
+	added, removed := m.stats.added, m.stats.removed
+	if added == 0 && removed == 0 {
+		return ""
+	}
+	return addedStyle.Render(fmt.Sprintf("+%d", added)) + " " +
+		removedStyle.Render(fmt.Sprintf("-%d", removed))
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		old, new       string
+		added, removed int
+	}{
+		{"a\nb", "a\nb", 0, 0},
+		{"a\nb\nc", "a\nc", 0, 1},
+		{"a", "a\nb", 1, 0},
+		{"", "x", 1, 0},
+		{"a\nb", "b\na", 1, 1},
+		{"a\nb\nc", "a\nB\nc", 1, 1},
+	}
+
+	for _, tt := range tests {
+		added, removed := diffLines(tt.old, tt.new)
+		if added != tt.added || removed != tt.removed {
+			t.Errorf("diffLines(%q, %q) = +%d -%d, want +%d -%d", tt.old, tt.new, added, removed, tt.added, tt.removed)
+		}
+	}
+}
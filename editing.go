@@ -0,0 +1,175 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// cursorColumn returns the column of the cursor within its (unwrapped) line.
+func cursorColumn(t textarea.Model) int {
+	li := t.LineInfo()
+	return li.StartColumn + li.ColumnOffset
+}
+
+// moveCursor places the cursor at row and col, clamping both to the bounds
+// of the document.
+//
+// Each loop also stops once the cursor doesn't move, so it can't spin on a
+// line the textarea won't leave.
+func moveCursor(t *textarea.Model, row, col int) {
+	if row > t.LineCount()-1 {
+		row = t.LineCount() - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	for t.Line() > row {
+		prev, prevCol := t.Line(), cursorColumn(*t)
+		t.CursorUp()
+		if t.Line() == prev && cursorColumn(*t) == prevCol {
+			break
+		}
+	}
+	for t.Line() < row {
+		prev, prevCol := t.Line(), cursorColumn(*t)
+		t.CursorDown()
+		if t.Line() == prev && cursorColumn(*t) == prevCol {
+			break
+		}
+	}
+	t.SetCursor(col)
+}
+
+// scrollToCursor focuses the editor and lets it scroll its view to the
+// cursor, which the textarea otherwise only does while handling input. It
+// scrolls within the lines it last drew, so it's drawn first.
+func (m *model) scrollToCursor() tea.Cmd {
+	cmd := m.input.Focus()
+	m.input.View()
+	m.input, _ = m.input.Update(nil)
+	return cmd
+}
+
+// currentLine returns the line the cursor is on.
+func currentLine(t textarea.Model) []rune {
+	lines := strings.Split(t.Value(), "\n")
+	return []rune(lines[t.Line()])
+}
+
+// setValue replaces the editor contents and places the cursor at row and col.
+func (m *model) setValue(value string, row, col int) {
+	m.clearMatches()
+	m.input.SetValue(value)
+	moveCursor(&m.input, row, col)
+	m.scrollToCursor()
+}
+
+// deleteLine removes line row from the document.
+func (m *model) deleteLine(row int) {
+	lines := strings.Split(m.input.Value(), "\n")
+	lines = append(lines[:row], lines[row+1:]...)
+	if row >= len(lines) {
+		row = len(lines) - 1
+	}
+	m.setValue(strings.Join(lines, "\n"), row, 0)
+}
+
+// taskItem matches a task list item up to its checkbox, capturing what's
+// in the box.
+var taskItem = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+\[([ xX])\]`)
+
+// toggleTask checks or unchecks the task list item on the cursor's line.
+// Other lines are left alone.
+func (m *model) toggleTask() {
+	row, col := m.input.Line(), cursorColumn(m.input)
+	lines := strings.Split(m.input.Value(), "\n")
+	match := taskItem.FindStringSubmatchIndex(lines[row])
+	if match == nil {
+		return
+	}
+
+	mark := "x"
+	if lines[row][match[2]:match[3]] != " " {
+		mark = " "
+	}
+	lines[row] = lines[row][:match[2]] + mark + lines[row][match[3]:]
+
+	m.checkpoint()
+	m.setValue(strings.Join(lines, "\n"), row, col)
+}
+
+// listMarker matches a list item's marker, capturing its indentation, the
+// bullet or number and the delimiter after a number, the space after the
+// marker and any task checkbox.
+var listMarker = regexp.MustCompile(`^(\s*)(?:([-*+])|(\d+)([.)]))(\s+)(\[[ xX]\]\s+)?`)
+
+// continueList handles enter on a list item, reporting whether it did. The
+// new line starts with the next item's marker, numbered one on from an
+// ordered item's and with an unchecked box after a task. Enter on an empty
+// item removes its marker instead, ending the list.
+func (m *model) continueList() bool {
+	row, col := m.input.Line(), cursorColumn(m.input)
+	line := string(currentLine(m.input))
+	match := listMarker.FindStringSubmatch(line)
+	if match == nil || col < len([]rune(match[0])) {
+		return false
+	}
+
+	if strings.TrimSpace(line[len(match[0]):]) == "" {
+		lines := strings.Split(m.input.Value(), "\n")
+		lines[row] = ""
+		m.setValue(strings.Join(lines, "\n"), row, 0)
+		return true
+	}
+
+	marker := match[2]
+	if marker == "" {
+		n, _ := strconv.Atoi(match[3])
+		marker = strconv.Itoa(n+1) + match[4]
+	}
+	if match[6] != "" {
+		marker += match[5] + "[ ]"
+	}
+	m.input.InsertString("\n" + match[1] + marker + " ")
+	return true
+}
+
+// shiftHeading promotes the heading on the cursor's line by delta levels, or
+// demotes it for a positive delta, keeping it between levels 1 and 6. The
+// textarea has no selection to span several headings, so only this one is
+// shifted.
+func (m *model) shiftHeading(delta int) {
+	row, col := m.input.Line(), cursorColumn(m.input)
+	value := m.input.Value()
+
+	for _, h := range parseHeadings(value, 6) {
+		if h.line != row {
+			continue
+		}
+
+		level := h.level + delta
+		if level < 1 {
+			level = 1
+		} else if level > 6 {
+			level = 6
+		}
+		if level == h.level {
+			return
+		}
+
+		lines := strings.Split(value, "\n")
+		i := strings.Index(lines[row], "#")
+		lines[row] = lines[row][:i] + strings.Repeat("#", level) + lines[row][i+h.level:]
+		if col > i {
+			col += level - h.level
+		}
+
+		m.checkpoint()
+		m.setValue(strings.Join(lines, "\n"), row, col)
+		return
+	}
+}
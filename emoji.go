@@ -0,0 +1,130 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// emoji maps GitHub's more common emoji shortcodes, without their colons,
+// to the characters they stand for.
+var emoji = map[string]string{
+	"+1":                    "👍",
+	"-1":                    "👎",
+	"100":                   "💯",
+	"angry":                 "😠",
+	"arrow_down":            "⬇️",
+	"arrow_left":            "⬅️",
+	"arrow_right":           "➡️",
+	"arrow_up":              "⬆️",
+	"beer":                  "🍺",
+	"bell":                  "🔔",
+	"blush":                 "😊",
+	"books":                 "📚",
+	"boom":                  "💥",
+	"bug":                   "🐛",
+	"bulb":                  "💡",
+	"calendar":              "📆",
+	"camera":                "📷",
+	"clap":                  "👏",
+	"clipboard":             "📋",
+	"coffee":                "☕",
+	"confused":              "😕",
+	"construction":          "🚧",
+	"cool":                  "🆒",
+	"cry":                   "😢",
+	"eyes":                  "👀",
+	"fire":                  "🔥",
+	"flushed":               "😳",
+	"gear":                  "⚙️",
+	"ghost":                 "👻",
+	"gift":                  "🎁",
+	"grin":                  "😁",
+	"grinning":              "😀",
+	"hammer":                "🔨",
+	"heart":                 "❤️",
+	"heart_eyes":            "😍",
+	"heavy_check_mark":      "✔️",
+	"hourglass":             "⌛",
+	"hugs":                  "🤗",
+	"information_source":    "ℹ️",
+	"joy":                   "😂",
+	"key":                   "🔑",
+	"laughing":              "😆",
+	"link":                  "🔗",
+	"lock":                  "🔒",
+	"mag":                   "🔍",
+	"memo":                  "📝",
+	"muscle":                "💪",
+	"no_entry":              "⛔",
+	"ok_hand":               "👌",
+	"package":               "📦",
+	"partying_face":         "🥳",
+	"pencil2":               "✏️",
+	"pray":                  "🙏",
+	"pushpin":               "📌",
+	"question":              "❓",
+	"raised_hands":          "🙌",
+	"recycle":               "♻️",
+	"relaxed":               "☺️",
+	"rocket":                "🚀",
+	"rotating_light":        "🚨",
+	"scream":                "😱",
+	"see_no_evil":           "🙈",
+	"shrug":                 "🤷",
+	"simple_smile":          "🙂",
+	"sleeping":              "😴",
+	"slightly_smiling_face": "🙂",
+	"smile":                 "😄",
+	"smiley":                "😃",
+	"smirk":                 "😏",
+	"sob":                   "😭",
+	"sparkles":              "✨",
+	"star":                  "⭐",
+	"sunglasses":            "😎",
+	"sweat_smile":           "😅",
+	"tada":                  "🎉",
+	"thinking":              "🤔",
+	"thumbsdown":            "👎",
+	"thumbsup":              "👍",
+	"trophy":                "🏆",
+	"warning":               "⚠️",
+	"wave":                  "👋",
+	"white_check_mark":      "✅",
+	"wink":                  "😉",
+	"wrench":                "🔧",
+	"x":                     "❌",
+	"zap":                   "⚡",
+}
+
+var shortcode = regexp.MustCompile(`:[a-z0-9_+-]+:`)
+
+// expandEmoji replaces the emoji shortcodes in s, like :smile:, with the
+// emoji themselves. Fenced code blocks and inline code are left alone, as
+// are shortcodes it doesn't know.
+func expandEmoji(s string) string {
+	if !strings.Contains(s, ":") {
+		return s
+	}
+
+	lines := strings.Split(s, "\n")
+	var fence codeFence
+	for i, line := range lines {
+		if fence.next(line) != outsideFence {
+			continue
+		}
+
+		// Code spans are the odd numbered pieces between backticks.
+		spans := strings.Split(line, "`")
+		for j := 0; j < len(spans); j += 2 {
+			spans[j] = shortcode.ReplaceAllStringFunc(spans[j], func(code string) string {
+				if e, ok := emoji[code[1:len(code)-1]]; ok {
+					return e
+				}
+				return code
+			})
+		}
+		lines[i] = strings.Join(spans, "`")
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+const (
+	lf   = "\n"
+	crlf = "\r\n"
+
+	// bom is the UTF-8 byte order mark some Windows editors start files
+	// with.
+	bom = "\ufeff"
+)
+
+// lineEnding reports which line ending most of the lines in s end with.
+// Files without line breaks get LF.
+func lineEnding(s string) string {
+	n := strings.Count(s, crlf)
+	if n > 0 && n >= strings.Count(s, lf)-n {
+		return crlf
+	}
+	return lf
+}
+
+// withLineEnding rewrites the LF line endings in s to ending.
+func withLineEnding(s, ending string) string {
+	if ending == lf {
+		return s
+	}
+	return strings.ReplaceAll(s, lf, ending)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var htmlDocumentTemplate = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+</head>
+<body>
+{{ .Body }}
+</body>
+</html>
+`))
+
+// markdownToHTML converts markdown to HTML, GitHub flavoured.
+func markdownToHTML(markdown string) (string, error) {
+	var b bytes.Buffer
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	if err := md.Convert([]byte(markdown), &b); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// renderHTML converts markdown to a standalone HTML document.
+func renderHTML(title, markdown string) ([]byte, error) {
+	body, err := markdownToHTML(markdown)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc bytes.Buffer
+	err = htmlDocumentTemplate.Execute(&doc, struct {
+		Title string
+		Body  template.HTML
+	}{
+		Title: title,
+		Body:  template.HTML(body),
+	})
+	return doc.Bytes(), err
+}
+
+// exportPath returns path with its extension swapped for ext.
+func exportPath(path, ext string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ext
+}
+
+// exportHTML writes the document as HTML next to the markdown file and
+// returns the path written.
+func exportHTML(m model) (string, error) {
+	if m.filePath == "" {
+		return "", errors.New("save the file before exporting it")
+	}
+
+	doc, err := renderHTML(m.title, m.input.Value())
+	if err != nil {
+		return "", err
+	}
+
+	path := exportPath(m.filePath, ".html")
+	return path, m.store.Save(path, bytes.NewReader(doc))
+}
+
+// exportText writes the document as hard-wrapped plain text next to the
+// markdown file and returns the path written.
+func exportText(m model) (string, error) {
+	if m.filePath == "" {
+		return "", errors.New("save the file before exporting it")
+	}
+
+	path := exportPath(m.filePath, ".txt")
+	doc := renderText(m.input.Value(), m.wrapWidth)
+	return path, m.store.Save(path, strings.NewReader(doc))
+}
+
+// exportTempHTML writes the document as HTML to a temporary file, for
+// opening in a browser or converting, and returns the path written.
+func exportTempHTML(m model) (string, error) {
+	doc, err := renderHTML(m.title, m.input.Value())
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp("", "markaway-*.html")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(doc); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
+
+// openCommand returns the command that opens path with the system's default
+// handler for it.
+func openCommand(path string) *exec.Cmd {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", path)
+	case "windows":
+		return exec.Command("cmd", "/c", "start", "", path)
+	}
+	return exec.Command("xdg-open", path)
+}
@@ -0,0 +1,56 @@
+package main
+
+import "strings"
+
+// fenceLine is where a line falls relative to the fenced code blocks of a
+// document.
+type fenceLine int
+
+const (
+	outsideFence fenceLine = iota
+	openingFence
+	insideFence
+	closingFence
+)
+
+// codeFence follows the fenced code blocks of a document read a line at a
+// time. A block is closed by a fence of the same character that's at least
+// as long as the one that opened it, so a shorter fence or one with an info
+// string, like ```go, is part of the code.
+type codeFence struct {
+	// marker is the fence that opened the block being read, or empty
+	// outside a block.
+	marker string
+	// info is the info string of the opening fence, such as the language.
+	info string
+}
+
+// next reads the next line of the document and reports where it falls.
+func (f *codeFence) next(line string) fenceLine {
+	trimmed := strings.TrimSpace(line)
+
+	if f.marker != "" {
+		c := f.marker[:1]
+		if len(trimmed) >= len(f.marker) && strings.Trim(trimmed, c) == "" {
+			f.marker, f.info = "", ""
+			return closingFence
+		}
+		return insideFence
+	}
+
+	for _, c := range []string{"`", "~"} {
+		rest := strings.TrimLeft(trimmed, c)
+		n := len(trimmed) - len(rest)
+		if n < 3 || (c == "`" && strings.Contains(rest, "`")) {
+			continue
+		}
+		f.marker, f.info = trimmed[:n], strings.TrimSpace(rest)
+		return openingFence
+	}
+	return outsideFence
+}
+
+// open reports whether the lines read so far end inside a fenced code block.
+func (f *codeFence) open() bool {
+	return f.marker != ""
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeFence(t *testing.T) {
+	const (
+		out  = outsideFence
+		open = openingFence
+		in   = insideFence
+		end  = closingFence
+	)
+	tests := []struct {
+		name string
+		in   string
+		want []fenceLine
+	}{
+		{
+			name: "backticks",
+			in:   "text\n```\ncode\n```\ntext",
+			want: []fenceLine{out, open, in, end, out},
+		},
+		{
+			name: "tildes",
+			in:   "~~~\n```\n~~~",
+			want: []fenceLine{open, in, end},
+		},
+		{
+			name: "info string doesn't close",
+			in:   "```\n```go\n```",
+			want: []fenceLine{open, in, end},
+		},
+		{
+			name: "longer fence",
+			in:   "````md\n```go\n```\n````",
+			want: []fenceLine{open, in, in, end},
+		},
+		{
+			name: "closing fence can be longer",
+			in:   "```\ncode\n`````",
+			want: []fenceLine{open, in, end},
+		},
+		{
+			name: "two characters isn't a fence",
+			in:   "``\ntext",
+			want: []fenceLine{out, out},
+		},
+		{
+			name: "inline code isn't a fence",
+			in:   "```code```\ntext",
+			want: []fenceLine{out, out},
+		},
+		{
+			name: "indented",
+			in:   "  ```\n  code\n  ```",
+			want: []fenceLine{open, in, end},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				f   codeFence
+				got []fenceLine
+			)
+			for _, line := range strings.Split(tt.in, "\n") {
+				got = append(got, f.next(line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeFenceInfo(t *testing.T) {
+	var f codeFence
+	f.next("````  mermaid ")
+	if f.marker != "````" || f.info != "mermaid" {
+		t.Errorf("marker, info = %q, %q, want %q, %q", f.marker, f.info, "````", "mermaid")
+	}
+	if !f.open() {
+		t.Error("open() = false after an opening fence")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import tea "github.com/charmbracelet/bubbletea"
+
+// Terminals report when they gain and lose focus once focus reporting is
+// enabled. Bubble Tea doesn't know the sequences they use, and delivers
+// them as alt key presses instead.
+const (
+	enableFocusReporting  = "\x1b[?1004h"
+	disableFocusReporting = "\x1b[?1004l"
+
+	focusInKey  = "alt+[I"
+	focusOutKey = "alt+[O"
+)
+
+// isFocusKey reports whether msg is really the terminal reporting a change
+// of focus.
+func isFocusKey(msg tea.KeyMsg) bool {
+	return msg.String() == focusInKey || msg.String() == focusOutKey
+}
+
+// blurred saves the buffer when the terminal loses focus, if it has changes
+// and saveOnBlur is set.
+func (m *model) blurred() tea.Cmd {
+	if !m.saveOnBlur || !m.dirty || m.savePath() == "" || m.saving || m.readOnly {
+		return nil
+	}
+	return m.startSave(blurSave)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var foldStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+// maxInputRows is as tall as the textarea grows. Folded lines are hidden
+// from a textarea made taller to hold them, so no more than this less the
+// pane's height can be folded at once.
+const maxInputRows = 99
+
+// fold is a section collapsed under its heading. The heading on line start
+// stays in view while the lines after it, up to and including end, are
+// hidden behind a placeholder. The text itself is left alone.
+type fold struct {
+	start, end int
+}
+
+// toggleFold folds the section the cursor is in under its heading, or
+// unfolds it again when the cursor is on a folded heading.
+func (m *model) toggleFold() tea.Cmd {
+	row := m.input.Line()
+	for i, f := range m.folds {
+		if f.start == row {
+			m.folds = append(m.folds[:i], m.folds[i+1:]...)
+			m.sizeInputs()
+			return m.scrollToCursor()
+		}
+	}
+
+	headings := parseHeadings(m.input.Value(), 6)
+	i := sort.Search(len(headings), func(i int) bool { return headings[i].line > row }) - 1
+	if i < 0 {
+		return m.setStatus("Not under a heading", true)
+	}
+
+	// A section runs until the next heading at the same level or above.
+	h := headings[i]
+	end := m.input.LineCount() - 1
+	for _, next := range headings[i+1:] {
+		if next.level <= h.level {
+			end = next.line - 1
+			break
+		}
+	}
+	if end <= h.line {
+		return m.setStatus("Nothing to fold under this heading", true)
+	}
+
+	// Folds inside the new one are folded away with it.
+	folds := []fold{}
+	for _, f := range m.folds {
+		if f.start < h.line || f.start > end {
+			folds = append(folds, f)
+		}
+	}
+	folds = append(folds, fold{h.line, end})
+	sort.Slice(folds, func(i, j int) bool { return folds[i].start < folds[j].start })
+	if m.inputRows()+foldedLines(folds) > maxInputRows {
+		return m.setStatus(fmt.Sprintf("Can't fold more than %d lines at once", maxInputRows-m.inputRows()), true)
+	}
+	m.folds = folds
+
+	moveCursor(&m.input, h.line, cursorColumn(m.input))
+	m.sizeInputs()
+	return m.scrollToCursor()
+}
+
+// foldedLines is the number of lines hidden by folds.
+func (m model) foldedLines() int {
+	return foldedLines(m.folds)
+}
+
+func foldedLines(folds []fold) int {
+	n := 0
+	for _, f := range folds {
+		n += f.end - f.start
+	}
+	return n
+}
+
+// fitFolds unfolds the last folds until the rest fit in the textarea, for
+// when the pane has grown taller.
+func (m *model) fitFolds() {
+	for len(m.folds) > 0 && m.inputRows()+m.foldedLines() > maxInputRows {
+		m.folds = m.folds[:len(m.folds)-1]
+	}
+}
+
+// folded returns the fold hiding line, if there is one.
+func (m model) folded(line int) (fold, bool) {
+	for _, f := range m.folds {
+		if line > f.start && line <= f.end {
+			return f, true
+		}
+	}
+	return fold{}, false
+}
+
+// adjustFolds keeps the folds in step with an update that started out with
+// the cursor on row of a document lines long. Edits that add or remove lines
+// move the folds after them, and open the ones they were made in. A cursor
+// that ended up on a hidden line is moved past the fold the way it was
+// going.
+func (m *model) adjustFolds(row, lines int) {
+	if len(m.folds) == 0 {
+		return
+	}
+
+	if delta := m.input.LineCount() - lines; delta != 0 {
+		at := row
+		if m.input.Line() < at {
+			at = m.input.Line()
+		}
+		folds := []fold{}
+		for _, f := range m.folds {
+			switch {
+			case f.end < at:
+				folds = append(folds, f)
+			case f.start > at:
+				folds = append(folds, fold{f.start + delta, f.end + delta})
+			}
+		}
+		m.folds = folds
+		m.sizeInputs()
+	}
+
+	f, ok := m.folded(m.input.Line())
+	if !ok {
+		return
+	}
+	target := f.start
+	if m.input.Line() > row && f.end+1 < m.input.LineCount() {
+		target = f.end + 1
+	}
+	moveCursor(&m.input, target, cursorColumn(m.input))
+	if m.input.Focused() {
+		m.scrollToCursor()
+	}
+}
+
+// inputRows is how many rows of the editor are shown. The textarea is made
+// taller by the number of folded lines, so that what's left once they're
+// hidden still fills the pane.
+func (m model) inputRows() int {
+	if !m.zen {
+		return m.editorHeight()
+	}
+	if height := m.height - 1; height > minBodyHeight {
+		return height
+	}
+	return minBodyHeight
+}
+
+// foldView hides the folded lines among the rendered editor rows, whose line
+// number gutter starts left cells in and which end right cells before their
+// end. Each fold leaves a placeholder, and the rows are cut down to the
+// pane's height keeping the cursor in view.
+func (m model) foldView(rows []string, left, right int) []string {
+	if len(m.folds) == 0 {
+		return rows
+	}
+
+	var (
+		shown  []string
+		cursor int
+		line   = -1
+	)
+	for _, row := range rows {
+		// Wrapped rows have a blank gutter and belong to the line above.
+		plain := []rune(ansiEscape.ReplaceAllString(row, ""))
+		if left+lineNumberWidth <= len(plain) {
+			if n, err := strconv.Atoi(strings.TrimSpace(string(plain[left : left+lineNumberWidth]))); err == nil {
+				line = n - 1
+				if line == m.input.Line() {
+					cursor = len(shown)
+				}
+			} else if strings.TrimSpace(string(plain[left:left+lineNumberWidth])) != "" {
+				line = -1
+			}
+		}
+
+		f, ok := m.folded(line)
+		if !ok {
+			shown = append(shown, row)
+			continue
+		}
+		if line == f.start+1 && strings.TrimSpace(string(plain[left:left+lineNumberWidth])) != "" {
+			width := lipgloss.Width(row)
+			text := fmt.Sprintf("… %d lines", f.end-f.start)
+			shown = append(shown, ansiSlice(row, 0, left)+strings.Repeat(" ", lineNumberWidth)+
+				foldStyle.Render(ansiSlice(text, 0, width-left-right-lineNumberWidth))+
+				ansiSlice(row, width-right, right))
+		}
+	}
+
+	height := m.inputRows()
+	start := 0
+	if cursor >= height {
+		start = cursor - height + 1
+	}
+	if start+height > len(shown) {
+		return shown[start:]
+	}
+	return shown[start : start+height]
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	frontMatterYAML = "yaml"
+	frontMatterTOML = "toml"
+	frontMatterJSON = "json"
+	frontMatterNone = "none"
+)
+
+// frontMatterTemplates render the pairs of an orderedMap in each supported
+// front matter format, fences included.
+var frontMatterTemplates = map[string]string{
+	frontMatterYAML: `---
+{{ range .Pairs }}{{.Key}}:{{ yamlValue .Value }}
+{{ end }}---
+`,
+	frontMatterTOML: `+++
+{{ range .Pairs }}{{.Key}} = {{ tomlValue .Value }}
+{{ end }}+++
+`,
+	// JSON is fenced by its own braces.
+	frontMatterJSON: `{{ jsonObject . }}
+`,
+}
+
+var frontMatterFuncs = template.FuncMap{
+	"yamlValue":  yamlValue,
+	"tomlValue":  tomlValue,
+	"jsonObject": jsonObject,
+}
+
+// frontMatterFences are the opening lines recognized as the start of a front
+// matter block, mapped to the line that closes them.
+var frontMatterFences = map[string]string{
+	"---": "---",
+	"+++": "+++",
+}
+
+// orderedMap is a string map that remembers the order keys were added in, so
+// front matter can be written back out the way it was read.
+type orderedMap struct {
+	keys   []string
+	values map[string]string
+}
+
+type pair struct {
+	Key, Value string
+}
+
+func (o orderedMap) Get(key string) (string, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Set updates key in place, or appends it if it isn't present yet.
+func (o *orderedMap) Set(key, value string) {
+	if o.values == nil {
+		o.values = map[string]string{}
+	}
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+func (o orderedMap) Pairs() []pair {
+	pairs := make([]pair, 0, len(o.keys))
+	for _, k := range o.keys {
+		pairs = append(pairs, pair{Key: k, Value: o.values[k]})
+	}
+	return pairs
+}
+
+// Without returns a copy of o with key removed.
+func (o orderedMap) Without(key string) orderedMap {
+	var c orderedMap
+	for _, p := range o.Pairs() {
+		if p.Key != key {
+			c.Set(p.Key, p.Value)
+		}
+	}
+	return c
+}
+
+func (o orderedMap) Clone() orderedMap {
+	var c orderedMap
+	for _, p := range o.Pairs() {
+		c.Set(p.Key, p.Value)
+	}
+	return c
+}
+
+// MarshalJSON writes o as a JSON object, keeping its keys in order.
+func (o orderedMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, p := range o.Pairs() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(p.Key)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.WriteString(jsonValue(p.Value))
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// formatFrontMatter writes the pairs of o as a front matter block in format.
+func formatFrontMatter(format string, o orderedMap) (string, error) {
+	t, err := template.New("").Funcs(frontMatterFuncs).Parse(frontMatterTemplates[format])
+	if err != nil {
+		return "", fmt.Errorf("generating front matter: %w", err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, o); err != nil {
+		return "", fmt.Errorf("rendering front matter: %w", err)
+	}
+	return b.String(), nil
+}
+
+// parseFrontMatter splits a leading front matter block off of content,
+// returning its key/value pairs along with the remaining markdown body. If
+// content doesn't open with a fence it is returned untouched, as it is when
+// the block has lines that aren't key/value pairs, since then the fence was
+// a thematic break and the rest of the block markdown.
+func parseFrontMatter(content string) (orderedMap, string) {
+	var fields orderedMap
+
+	lines := strings.Split(content, "\n")
+	if strings.HasPrefix(strings.TrimSpace(lines[0]), "{") {
+		return parseJSONFrontMatter(content)
+	}
+	closing, ok := frontMatterFences[strings.TrimSpace(lines[0])]
+	if !ok {
+		return fields, content
+	}
+
+	var last string
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == closing {
+			return fields, strings.Join(lines[i+1:], "\n")
+		}
+
+		// Indented lines and YAML list items continue the previous key's
+		// value, as in a block sequence of tags.
+		if last != "" && (strings.HasPrefix(lines[i], " ") || strings.HasPrefix(line, "- ")) {
+			v, _ := fields.Get(last)
+			fields.Set(last, v+"\n"+lines[i])
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+		k, v, ok := splitFrontMatterLine(line)
+		if !ok {
+			return orderedMap{}, content
+		}
+		fields.Set(k, v)
+		last = k
+	}
+
+	// No closing fence, so this was never front matter to begin with.
+	return orderedMap{}, content
+}
+
+// parseJSONFrontMatter splits a leading JSON object off of content, as
+// parseFrontMatter does for fenced front matter. Arrays become YAML block
+// sequences, as they're kept for the other formats.
+func parseJSONFrontMatter(content string) (orderedMap, string) {
+	var fields orderedMap
+
+	dec := json.NewDecoder(strings.NewReader(content))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return orderedMap{}, content
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		key, ok := tok.(string)
+		if err != nil || !ok {
+			return orderedMap{}, content
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return orderedMap{}, content
+		}
+		fields.Set(key, fromJSONValue(value))
+	}
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('}') {
+		return orderedMap{}, content
+	}
+
+	// The body starts on the line after the closing brace.
+	rest := content[dec.InputOffset():]
+	i := strings.IndexByte(rest, '\n')
+	switch {
+	case i >= 0 && strings.TrimSpace(rest[:i]) == "":
+		return fields, rest[i+1:]
+	case strings.TrimSpace(rest) == "":
+		return fields, ""
+	}
+	return orderedMap{}, content
+}
+
+// fromJSONValue converts a JSON front matter value to the form values are
+// kept in: strings unquoted, and arrays of them as YAML block sequences.
+func fromJSONValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return keepQuoted(s)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err == nil && len(items) > 0 {
+		var b strings.Builder
+		for _, item := range items {
+			b.WriteString("\n- " + fromJSONValue(item))
+		}
+		return b.String()
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err != nil {
+		return string(raw)
+	}
+	return compact.String()
+}
+
+// splitFrontMatterLine parses a single `key = "value"` or `key: value` line.
+func splitFrontMatterLine(line string) (string, string, bool) {
+	i := strings.IndexAny(line, "=:")
+	if i < 0 {
+		return "", "", false
+	}
+
+	k := strings.TrimSpace(line[:i])
+	v := strings.TrimSpace(line[i+1:])
+	if k == "" {
+		return "", "", false
+	}
+
+	if unquoted, err := strconv.Unquote(v); err == nil {
+		return k, keepQuoted(unquoted), true
+	}
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		return k, keepQuoted(v[1 : len(v)-1]), true
+	}
+	return k, strings.Trim(v, `"'`), true
+}
+
+// keepQuoted returns the quoted string s as it's kept: unquoted, unless
+// that would turn it into a boolean, null or number, in which case it keeps
+// its quotes so it's written back out as a string.
+func keepQuoted(s string) string {
+	if isScalarLiteral(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// isScalarLiteral reports whether s, unquoted, reads as something other
+// than a string: a boolean, null or number, or nothing at all.
+func isScalarLiteral(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "~", "null", "true", "false", "yes", "no", "on", "off", "y", "n":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	_, err := strconv.ParseInt(s, 0, 64)
+	return err == nil
+}
+
+// isQuoted reports whether v is a single double quoted string.
+func isQuoted(v string) bool {
+	_, err := strconv.Unquote(v)
+	return err == nil && strings.HasPrefix(v, `"`)
+}
+
+// yamlValue formats v to follow a `key:`, keeping block values on the lines
+// below their key. Values that YAML would read as something else, or not at
+// all, are quoted: those with a `: ` or ` #` in them, and those starting
+// with an indicator character. Flow sequences and mappings, and values
+// already quoted, are written as they are.
+func yamlValue(v string) string {
+	if strings.HasPrefix(v, "\n") || v == "" {
+		return v
+	}
+	if isQuoted(v) || isFlowCollection(v) {
+		return " " + v
+	}
+	if strings.Contains(v, ": ") || strings.Contains(v, " #") || strings.HasSuffix(v, ":") ||
+		strings.ContainsAny(v[:1], "#&*!|>'\"%@`[{,?-") && !isPlainDash(v) ||
+		strings.TrimSpace(v) != v || strings.Contains(v, "\n") {
+		return " " + strconv.Quote(v)
+	}
+	return " " + v
+}
+
+// isPlainDash reports whether v starts with a dash that YAML doesn't read
+// as a list item, as in a negative number.
+func isPlainDash(v string) bool {
+	return strings.HasPrefix(v, "-") && !strings.HasPrefix(v, "- ") && v != "-"
+}
+
+// isFlowCollection reports whether v is a YAML flow sequence or mapping,
+// like `[go, notes]`.
+func isFlowCollection(v string) bool {
+	return strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]") ||
+		strings.HasPrefix(v, "{") && strings.HasSuffix(v, "}")
+}
+
+// tomlValue quotes v unless it's already a TOML literal such as a number,
+// boolean, array or inline table. YAML block sequences become arrays.
+func tomlValue(v string) string {
+	if strings.HasPrefix(v, "\n") {
+		var items []string
+		for _, line := range strings.Split(strings.TrimSpace(v), "\n") {
+			item := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+			items = append(items, tomlValue(strings.Trim(item, `"'`)))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	if isQuoted(v) || v == "true" || v == "false" || strings.HasPrefix(v, "[") || strings.HasPrefix(v, "{") {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+// jsonObject formats the pairs of o as an indented JSON object.
+func jsonObject(o orderedMap) (string, error) {
+	b, err := json.MarshalIndent(o, "", "  ")
+	return string(b), err
+}
+
+// jsonValue formats v as JSON, leaving it as it is when it's already a JSON
+// literal such as a number, boolean, array or object. YAML block sequences
+// become arrays.
+func jsonValue(v string) string {
+	if strings.HasPrefix(v, "\n") {
+		var items []string
+		for _, line := range strings.Split(strings.TrimSpace(v), "\n") {
+			item := strings.TrimPrefix(strings.TrimSpace(line), "- ")
+			items = append(items, jsonValue(strings.Trim(item, `"'`)))
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	if v == "true" || v == "false" || v == "null" || isQuoted(v) && json.Valid([]byte(v)) {
+		return v
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil && json.Valid([]byte(v)) {
+		return v
+	}
+	if (strings.HasPrefix(v, "[") || strings.HasPrefix(v, "{")) && json.Valid([]byte(v)) {
+		return v
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
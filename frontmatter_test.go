@@ -0,0 +1,190 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFrontMatterRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		in     string
+		want   string
+	}{
+		{
+			name:   "plain values",
+			format: frontMatterYAML,
+			in:     "---\ntitle: Hello world\ndraft: true\ncount: 3\n---\n",
+			want:   "---\ntitle: Hello world\ndraft: true\ncount: 3\n---\n",
+		},
+		{
+			name:   "colon in a value",
+			format: frontMatterYAML,
+			in:     "---\ntitle: \"Notes: part 1\"\n---\n",
+			want:   "---\ntitle: \"Notes: part 1\"\n---\n",
+		},
+		{
+			name:   "comment character",
+			format: frontMatterYAML,
+			in:     "---\ntags: \"#go\"\nnote: 'a #b'\n---\n",
+			want:   "---\ntags: \"#go\"\nnote: \"a #b\"\n---\n",
+		},
+		{
+			name:   "indicator characters",
+			format: frontMatterYAML,
+			in:     "---\na: \"*star\"\nb: \"&anchor\"\nc: \"[not a list\"\nd: \"- item\"\ne: \"`code`\"\n---\n",
+			want:   "---\na: \"*star\"\nb: \"&anchor\"\nc: \"[not a list\"\nd: \"- item\"\ne: \"`code`\"\n---\n",
+		},
+		{
+			name:   "quoted literals stay strings",
+			format: frontMatterYAML,
+			in:     "---\nversion: \"1.0\"\nanswer: 'yes'\nnothing: \"null\"\nempty: \"\"\n---\n",
+			want:   "---\nversion: \"1.0\"\nanswer: \"yes\"\nnothing: \"null\"\nempty: \"\"\n---\n",
+		},
+		{
+			name:   "sequences",
+			format: frontMatterYAML,
+			in:     "---\ntags: [go, notes]\nlist:\n- a\n- b\nnegative: -3\n---\n",
+			want:   "---\ntags: [go, notes]\nlist:\n- a\n- b\nnegative: -3\n---\n",
+		},
+		{
+			name:   "toml",
+			format: frontMatterTOML,
+			in:     "+++\ntitle = \"Notes: part 1\"\nversion = \"1.0\"\ndraft = true\n+++\n",
+			want:   "+++\ntitle = \"Notes: part 1\"\nversion = \"1.0\"\ndraft = true\n+++\n",
+		},
+		{
+			name:   "json",
+			format: frontMatterJSON,
+			in:     "{\n  \"title\": \"Notes: part 1\",\n  \"version\": \"1.0\",\n  \"count\": 3\n}\n",
+			want:   "{\n  \"title\": \"Notes: part 1\",\n  \"version\": \"1.0\",\n  \"count\": 3\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, body := parseFrontMatter(tt.in + "body")
+			if body != "body" {
+				t.Fatalf("body = %q, want %q", body, "body")
+			}
+
+			got, err := formatFrontMatter(tt.format, fields)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("formatFrontMatter() = %q, want %q", got, tt.want)
+			}
+
+			again, _ := parseFrontMatter(got + "body")
+			if !reflect.DeepEqual(again.Pairs(), fields.Pairs()) {
+				t.Errorf("reparsed %v, want %v", again.Pairs(), fields.Pairs())
+			}
+		})
+	}
+}
+
+func TestYAMLValue(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"hello", " hello"},
+		{"Notes: part 1", ` "Notes: part 1"`},
+		{"#go", ` "#go"`},
+		{"go #1", ` "go #1"`},
+		{"ends with:", ` "ends with:"`},
+		{"!important", ` "!important"`},
+		{"|pipe", ` "|pipe"`},
+		{">folded", ` ">folded"`},
+		{"%percent", ` "%percent"`},
+		{"@handle", ` "@handle"`},
+		{"'single", ` "'single"`},
+		{" padded", ` " padded"`},
+		{"-1.5", " -1.5"},
+		{"true", " true"},
+		{`"1.0"`, ` "1.0"`},
+		{"[a, b]", " [a, b]"},
+		{"{a: 1}", " {a: 1}"},
+		{"\n- a\n- b", "\n- a\n- b"},
+	}
+
+	for _, tt := range tests {
+		if got := yamlValue(tt.in); got != tt.want {
+			t.Errorf("yamlValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseFrontMatterThematicBreak(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		wantKeys int
+		wantBody string
+	}{
+		{
+			name:     "front matter",
+			in:       "---\ntitle: Notes\n\ntags:\n- a\n---\nbody",
+			wantKeys: 2,
+			wantBody: "body",
+		},
+		{
+			name:     "thematic break",
+			in:       "---\n\nJust a paragraph.\n\n---\nbody",
+			wantBody: "---\n\nJust a paragraph.\n\n---\nbody",
+		},
+		{
+			name:     "paragraph with a colon",
+			in:       "---\nNote: this is markdown\nand so is this\n---\n",
+			wantBody: "---\nNote: this is markdown\nand so is this\n---\n",
+		},
+		{
+			name:     "no closing fence",
+			in:       "---\ntitle: Notes\n",
+			wantBody: "---\ntitle: Notes\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, body := parseFrontMatter(tt.in)
+			if len(fields.Pairs()) != tt.wantKeys {
+				t.Errorf("got %d keys, want %d", len(fields.Pairs()), tt.wantKeys)
+			}
+			if body != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestFileContentKeepsFrontMatter(t *testing.T) {
+	m := newModel(options{
+		content:     "---\nzeta: 1\ntitle: Notes\nalpha: \"#x\"\n---\nbody\n",
+		frontMatter: frontMatterYAML,
+		theme:       defaultTheme,
+	})
+
+	got, err := fileContent(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, body := parseFrontMatter(got)
+	if body != "body\n" {
+		t.Errorf("body = %q, want %q", body, "body\n")
+	}
+
+	var keys []string
+	for _, p := range fields.Pairs() {
+		keys = append(keys, p.Key)
+	}
+	want := []string{"zeta", "title", "alpha", "user", "time"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("keys = %q, want %q", keys, want)
+	}
+	if v, _ := fields.Get("alpha"); v != "#x" {
+		t.Errorf("alpha = %q, want %q", v, "#x")
+	}
+}
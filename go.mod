@@ -3,16 +3,17 @@ module github.com/dalanmiller/markaway/v2
 go 1.19
 
 require (
+	github.com/alecthomas/chroma v0.8.2
+	github.com/atotto/clipboard v0.1.4
 	github.com/charmbracelet/bubbles v0.14.0
 	github.com/charmbracelet/bubbletea v0.22.1
 	github.com/charmbracelet/glamour v0.2.1-0.20210402234443-abe9cda419ba
 	github.com/charmbracelet/glow v1.4.1
 	github.com/charmbracelet/lipgloss v0.6.0
+	github.com/yuin/goldmark v1.3.1
 )
 
 require (
-	github.com/alecthomas/chroma v0.8.2 // indirect
-	github.com/atotto/clipboard v0.1.4 // indirect
 	github.com/aymerick/douceur v0.2.0 // indirect
 	github.com/calmh/randomart v1.1.0 // indirect
 	github.com/charmbracelet/charm v0.8.6 // indirect
@@ -57,7 +58,6 @@ require (
 	github.com/spf13/pflag v1.0.5 // indirect
 	github.com/spf13/viper v1.7.1 // indirect
 	github.com/subosito/gotenv v1.2.0 // indirect
-	github.com/yuin/goldmark v1.3.1 // indirect
 	github.com/yuin/goldmark-emoji v1.0.1 // indirect
 	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad // indirect
 	golang.org/x/net v0.0.0-20221002022538-bcab6841153b // indirect
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// imageExtensions are the file extensions offered by the image picker.
+var imageExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".svg":  true,
+	".webp": true,
+}
+
+// imageDir is the directory images are picked from: the assets directory if
+// one was given, otherwise the one the document is in.
+func (m model) imageDir() string {
+	if m.assetsDir != "" {
+		return m.assetsDir
+	}
+	if m.filePath != "" {
+		return filepath.Dir(m.filePath)
+	}
+	return "."
+}
+
+// listImages returns the names of the image files in dir, sorted.
+func listImages(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, e := range entries {
+		if !e.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(e.Name()))] {
+			images = append(images, e.Name())
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// imageLink is the path to put in a link to the image at path, relative to
+// the document, or to the working directory for a document with no file.
+func (m model) imageLink(path string) string {
+	base := "."
+	if m.filePath != "" {
+		base = filepath.Dir(m.filePath)
+	}
+
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+func (m *model) openImagePicker() tea.Cmd {
+	images, err := listImages(m.imageDir())
+	if err != nil {
+		m.mode = editMode
+		return tea.Batch(m.input.Focus(), m.setStatus(err.Error(), true))
+	}
+
+	m.images = images
+	m.imageCursor = 0
+	m.mode = imagePickMode
+	return nil
+}
+
+func (m model) updateImagePicker(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = editMode
+		return m, m.input.Focus()
+	case "up", "k":
+		if m.imageCursor > 0 {
+			m.imageCursor--
+		}
+	case "down", "j":
+		if m.imageCursor < len(m.images)-1 {
+			m.imageCursor++
+		}
+	case "enter":
+		if len(m.images) == 0 {
+			m.mode = editMode
+			return m, m.input.Focus()
+		}
+
+		// Carry on as the image component would, with the path filled in
+		// and alt text left to write.
+		url := newPromptInput("path")
+		url.SetValue(m.imageLink(filepath.Join(m.imageDir(), m.images[m.imageCursor])))
+		m.promptInputs = []textinput.Model{newPromptInput("alt text"), url}
+		m.promptFocus = 0
+		m.mode = componentPromptMode
+		return m, m.promptInputs[0].Focus()
+	}
+
+	return m, nil
+}
+
+func (m model) imagePickerView() string {
+	b := strings.Builder{}
+	b.WriteString("Pick an image from " + m.imageDir() + "\n\n")
+
+	if len(m.images) == 0 {
+		b.WriteString("  No images found\n")
+	}
+
+	start := 0
+	if m.imageCursor >= maxPaletteEntries {
+		start = m.imageCursor - maxPaletteEntries + 1
+	}
+	for i := start; i < len(m.images) && i < start+maxPaletteEntries; i++ {
+		if i == m.imageCursor {
+			b.WriteString(menuSelectedStyle.Render("> "+m.images[i]) + "\n")
+		} else {
+			b.WriteString("  " + m.images[i] + "\n")
+		}
+	}
+
+	b.WriteString("\nenter pick • esc cancel")
+	return menuStyle.Render(b.String())
+}
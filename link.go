@@ -0,0 +1,126 @@
+package main
+
+import (
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// titleTimeout is how long fetching a page's title may take.
+	titleTimeout = 5 * time.Second
+
+	// maxTitleBytes is how much of a page is read looking for its title.
+	maxTitleBytes = 64 << 10
+)
+
+var titleTag = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// linkTitleMsg carries the title fetched for a pasted URL, and where it
+// was pasted.
+type linkTitleMsg struct {
+	url    string
+	title  string
+	target linkTarget
+}
+
+// linkTarget is where a link was pasted: the buffer, and the cursor's
+// position in it.
+type linkTarget struct {
+	buffer   int
+	scratch  bool
+	path     string
+	row, col int
+}
+
+// linkTarget returns the buffer and cursor position a link pasted now goes
+// to.
+func (m model) linkTarget() linkTarget {
+	return linkTarget{
+		buffer:  m.active,
+		scratch: m.scratchActive,
+		path:    m.filePath,
+		row:     m.input.Line(),
+		col:     cursorColumn(m.input),
+	}
+}
+
+// isURL reports whether s is a single http or https URL.
+func isURL(s string) bool {
+	if strings.ContainsAny(s, " \t\n") {
+		return false
+	}
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// pasteLink pastes the clipboard. A URL is pasted as a markdown link titled
+// with the page's title once that's been fetched, anything else as it is.
+func (m *model) pasteLink() tea.Cmd {
+	s, err := clipboard.ReadAll()
+	if err != nil {
+		return m.setStatus("Could not read the clipboard: "+err.Error(), true)
+	}
+
+	s = strings.TrimSpace(s)
+	if !isURL(s) {
+		m.checkpoint()
+		m.input.InsertString(s)
+		return nil
+	}
+
+	return tea.Batch(m.setStatus("Fetching title of "+s, false), fetchTitle(s, m.linkTarget()))
+}
+
+// fetchTitle fetches the page at u and reports its <title>, or u itself if
+// that fails, for a link to be pasted at target.
+func fetchTitle(u string, target linkTarget) tea.Cmd {
+	return func() tea.Msg {
+		client := http.Client{Timeout: titleTimeout}
+		resp, err := client.Get(u)
+		if err != nil {
+			return linkTitleMsg{u, u, target}
+		}
+		defer resp.Body.Close()
+
+		b, err := io.ReadAll(io.LimitReader(resp.Body, maxTitleBytes))
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return linkTitleMsg{u, u, target}
+		}
+
+		match := titleTag.FindSubmatch(b)
+		if match == nil {
+			return linkTitleMsg{u, u, target}
+		}
+		title := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+		if title == "" {
+			title = u
+		}
+		return linkTitleMsg{u, title, target}
+	}
+}
+
+// insertLink inserts the link for a fetched title where it was pasted.
+// Brackets in the title are escaped so they don't end the link text early.
+// If the buffer it was pasted into has been left since, or the editor is
+// busy with something else, the link is dropped.
+func (m *model) insertLink(msg linkTitleMsg) tea.Cmd {
+	t, now := msg.target, m.linkTarget()
+	if m.mode != editMode || t.buffer != now.buffer || t.scratch != now.scratch || t.path != now.path {
+		return m.setStatus("Didn't paste the link to "+msg.url+": its buffer is no longer being edited", true)
+	}
+
+	title := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(msg.title)
+	m.checkpoint()
+	moveCursor(&m.input, t.row, t.col)
+	m.input.InsertString("[" + title + "](" + msg.url + ")")
+	m.status = ""
+	return nil
+}
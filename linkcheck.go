@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// linkCheckTimeout is how long checking a single link may take.
+	linkCheckTimeout = 10 * time.Second
+
+	// maxLinkChecks is how many links are checked at once.
+	maxLinkChecks = 8
+)
+
+var (
+	linkURL = regexp.MustCompile("https?://[^\\s<>\"'`)\\]]+")
+
+	// linkCheckSlots limits the checks running at once to maxLinkChecks.
+	linkCheckSlots = make(chan struct{}, maxLinkChecks)
+)
+
+// checkedLink is a link in the document and, once it's been checked, how
+// that went.
+type checkedLink struct {
+	url    string
+	line   int
+	status string
+	ok     bool
+	done   bool
+}
+
+// linkStatusMsg reports the outcome of checking a link. id ties it to the
+// check it was part of, so results of an earlier one are ignored.
+type linkStatusMsg struct {
+	id     int
+	url    string
+	status string
+	ok     bool
+}
+
+// extractLinks returns the http and https links in s in the order they
+// first appear, each listed once. Links in fenced code blocks are taken to
+// be examples and skipped.
+func extractLinks(s string) []checkedLink {
+	var (
+		links []checkedLink
+		seen  = map[string]bool{}
+		fence codeFence
+	)
+	for i, line := range strings.Split(s, "\n") {
+		if fence.next(line) != outsideFence {
+			continue
+		}
+
+		for _, u := range linkURL.FindAllString(line, -1) {
+			u = strings.TrimRight(u, ".,;:!?")
+			if !seen[u] {
+				seen[u] = true
+				links = append(links, checkedLink{url: u, line: i})
+			}
+		}
+	}
+	return links
+}
+
+// checkLink requests u and reports whether it resolves. Servers that don't
+// allow HEAD requests are asked with GET instead.
+func checkLink(id int, u string) tea.Cmd {
+	return func() tea.Msg {
+		linkCheckSlots <- struct{}{}
+		defer func() { <-linkCheckSlots }()
+
+		client := http.Client{Timeout: linkCheckTimeout}
+		resp, err := client.Head(u)
+		if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+			resp.Body.Close()
+			resp, err = client.Get(u)
+		}
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return linkStatusMsg{id, u, "timeout", false}
+			}
+			return linkStatusMsg{id, u, "unreachable", false}
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusBadRequest {
+			return linkStatusMsg{id, u, fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)), false}
+		}
+		return linkStatusMsg{id, u, "OK", true}
+	}
+}
+
+// openLinkCheck lists the links in the document and starts checking them,
+// filling in the list as the results come in.
+func (m *model) openLinkCheck() tea.Cmd {
+	m.links = extractLinks(m.input.Value())
+	m.linkCursor = 0
+	m.linkCheckID++
+	m.mode = linkCheckMode
+
+	cmds := make([]tea.Cmd, len(m.links))
+	for i, l := range m.links {
+		cmds[i] = checkLink(m.linkCheckID, l.url)
+	}
+	return tea.Batch(cmds...)
+}
+
+// finishLinkCheck records the outcome of checking a link.
+func (m *model) finishLinkCheck(msg linkStatusMsg) {
+	if msg.id != m.linkCheckID {
+		return
+	}
+	for i, l := range m.links {
+		if l.url == msg.url {
+			m.links[i].status = msg.status
+			m.links[i].ok = msg.ok
+			m.links[i].done = true
+		}
+	}
+}
+
+func (m model) updateLinkCheck(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keymap.checkLinks):
+		m.mode = editMode
+		return m, m.input.Focus()
+	case msg.String() == "up", msg.String() == "k":
+		if m.linkCursor > 0 {
+			m.linkCursor--
+		}
+	case msg.String() == "down", msg.String() == "j":
+		if m.linkCursor < len(m.links)-1 {
+			m.linkCursor++
+		}
+	case msg.String() == "r":
+		return m, m.openLinkCheck()
+	case msg.String() == "enter":
+		m.mode = editMode
+		if len(m.links) == 0 {
+			return m, m.input.Focus()
+		}
+		moveCursor(&m.input, m.links[m.linkCursor].line, 0)
+		cmd := m.scrollToCursor()
+		m.syncPreviewScroll()
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m model) linkCheckView() string {
+	checked := 0
+	for _, l := range m.links {
+		if l.done {
+			checked++
+		}
+	}
+
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "Links (%d of %d checked)\n\n", checked, len(m.links))
+
+	if len(m.links) == 0 {
+		b.WriteString("  No links found\n")
+	}
+	for i, l := range m.links {
+		status := "…"
+		if l.done {
+			status = l.status
+		}
+		entry := fmt.Sprintf("Ln %d  %s  %s", l.line+1, l.url, status)
+		switch {
+		case i == m.linkCursor:
+			b.WriteString(menuSelectedStyle.Render("> "+entry) + "\n")
+		case l.done && !l.ok:
+			b.WriteString("  " + statusErrorStyle.Render(entry) + "\n")
+		default:
+			b.WriteString("  " + entry + "\n")
+		}
+	}
+
+	b.WriteString("\nenter jump • r check again • esc close")
+	return menuStyle.Render(b.String())
+}
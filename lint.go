@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// lintIssue is a likely mistake in the markdown, on a zero-based line.
+type lintIssue struct {
+	line    int
+	message string
+}
+
+var (
+	listItem  = regexp.MustCompile(`^\s*([-*+]|\d+[.)])\s`)
+	emptyLink = regexp.MustCompile(`\[[^\]]*\]\(\s*\)`)
+)
+
+// lint checks markdown for common mistakes: lists without a blank line
+// before them, unclosed code fences, headings that skip a level and links
+// with no target.
+func lint(s string) []lintIssue {
+	var (
+		issues    []lintIssue
+		fence     codeFence
+		fenceLine int
+		level     int
+		prev      string
+	)
+
+	for i, line := range strings.Split(s, "\n") {
+		switch fence.next(line) {
+		case openingFence:
+			fenceLine = i
+			fallthrough
+		case insideFence, closingFence:
+			prev = line
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+
+		// Lines before a list that are indented or list items themselves
+		// belong to the list already, and headings end on their own.
+		if listItem.MatchString(line) && strings.TrimSpace(prev) != "" && !listItem.MatchString(prev) &&
+			!strings.HasPrefix(prev, " ") && !strings.HasPrefix(prev, "\t") && !strings.HasPrefix(prev, "#") {
+			issues = append(issues, lintIssue{i, "list needs a blank line before it"})
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			l := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+			if l <= 6 && strings.HasPrefix(trimmed[l:], " ") {
+				if level > 0 && l > level+1 {
+					issues = append(issues, lintIssue{i, fmt.Sprintf("heading skips from level %d to %d", level, l)})
+				}
+				level = l
+			}
+		}
+
+		if emptyLink.MatchString(line) {
+			issues = append(issues, lintIssue{i, "link has no target"})
+		}
+
+		prev = line
+	}
+
+	if fence.open() {
+		issues = append(issues, lintIssue{fenceLine, "code fence is never closed"})
+	}
+
+	return issues
+}
+
+func (m *model) openLint() {
+	m.lintCursor = 0
+	m.mode = lintMode
+}
+
+func (m model) updateLint(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keymap.lint):
+		m.mode = editMode
+		return m, m.input.Focus()
+	case msg.String() == "up", msg.String() == "k":
+		if m.lintCursor > 0 {
+			m.lintCursor--
+		}
+	case msg.String() == "down", msg.String() == "j":
+		if m.lintCursor < len(m.lintIssues)-1 {
+			m.lintCursor++
+		}
+	case msg.String() == "enter":
+		m.mode = editMode
+		if len(m.lintIssues) == 0 {
+			return m, m.input.Focus()
+		}
+		moveCursor(&m.input, m.lintIssues[m.lintCursor].line, 0)
+		cmd := m.scrollToCursor()
+		m.syncPreviewScroll()
+		return m, cmd
+	}
+
+	return m, nil
+}
+
+func (m model) lintView() string {
+	b := strings.Builder{}
+	b.WriteString("Problems\n\n")
+
+	if len(m.lintIssues) == 0 {
+		b.WriteString("  No problems found\n")
+	}
+	for i, issue := range m.lintIssues {
+		entry := fmt.Sprintf("Ln %d  %s", issue.line+1, issue.message)
+		if i == m.lintCursor {
+			b.WriteString(menuSelectedStyle.Render("> "+entry) + "\n")
+		} else {
+			b.WriteString("  " + entry + "\n")
+		}
+	}
+
+	b.WriteString("\nenter jump • esc close")
+	return menuStyle.Render(b.String())
+}
@@ -1,31 +1,71 @@
 package main
 
 import (
-	"bytes"
+	"errors"
 	"flag"
 	"fmt"
-	"html/template"
+	"io"
+	"io/fs"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/stopwatch"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
 	"github.com/charmbracelet/lipgloss"
 )
 
 const (
-	initialInputs = 2
-	maxInputs     = 6
-	minInputs     = 1
-	titleHeight   = 3
-	helpHeight    = 5
+	defaultTheme = "dark"
+	defaultTitle = "A New File"
+	defaultMode  = "0644"
+
+	defaultDateFormat = "2006-01-02"
+	timeOfDayFormat   = "15:04"
+	defaultDateField  = "date"
+
+	titleHeight = 3
+	helpHeight  = 5
+
+	// helpBarHeight is the part of helpHeight taken by the help bar and the
+	// blank line above it, which hiding the help bar frees up.
+	helpBarHeight = 2
+
+	statusTimeout = 3 * time.Second
+
+	// The editor's share of the window width when the preview is shown, or
+	// of its height when they're stacked.
+	defaultSplitRatio = 0.5
+	minSplitRatio     = 0.2
+	maxSplitRatio     = 0.8
+	splitRatioStep    = 0.1
+
+	// zenWidth is the widest the editor gets in zen mode.
+	zenWidth = 80
+
+	// minPreviewWidth is the narrowest terminal the preview is shown beside
+	// the editor in. minEditorWidth and minBodyHeight keep the panes from
+	// being sized to nothing, or less, on tiny terminals.
+	minPreviewWidth = 60
+	minEditorWidth  = 10
+	minBodyHeight   = 1
+
+	// minStackedHeight is the shortest body the preview is shown below the
+	// editor in.
+	minStackedHeight = 12
 )
 
 var (
@@ -50,16 +90,33 @@ var (
 
 	blurredBorderStyle = lipgloss.NewStyle().
 				Border(lipgloss.HiddenBorder())
+
+	statusStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("99"))
+
+	statusErrorStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("203"))
 )
 
 type keymap = struct {
-	next, insertComponent, prev, add, remove, save, quit key.Binding
+	next, insertComponent, prev, save, quit               key.Binding
+	togglePreview, rename, undo, redo, toc, exportHTML    key.Binding
+	shrinkEditor, growEditor, search, toggleWrap          key.Binding
+	palette, nextBuffer, prevBuffer                       key.Binding
+	insertDate, insertDateTime, zen, exportText           key.Binding
+	pauseTimer, lint, showHelp                            key.Binding
+	bold, italic, code, metadata, pasteLink, outline      key.Binding
+	copyMarkdown, copyHTML, toggleTask, openBrowser       key.Binding
+	toggleLayout, formatTable, saveAndQuit, toggleHelpBar key.Binding
+	promoteHeading, demoteHeading, toggleFold             key.Binding
+	checkLinks, exportPDF, toggleScratch                  key.Binding
 }
 
 func newTextarea() textarea.Model {
 	t := textarea.New()
 	t.Prompt = ""
 	t.Placeholder = "Type something"
+	t.CharLimit = 0
 	t.ShowLineNumbers = true
 	t.Cursor.Style = cursorStyle
 	t.FocusedStyle.Placeholder = focusedPlaceholderStyle
@@ -76,6 +133,38 @@ func newTextarea() textarea.Model {
 	return t
 }
 
+// layout is how the editor and preview are arranged when both are shown.
+type layout int
+
+const (
+	// horizontalLayout puts the preview beside the editor.
+	horizontalLayout layout = iota
+	// verticalLayout stacks the preview below the editor.
+	verticalLayout
+)
+
+type mode int
+
+const (
+	editMode mode = iota
+	confirmQuitMode
+	insertMenuMode
+	componentPromptMode
+	renameMode
+	tocMode
+	searchMode
+	matchMode
+	savePathMode
+	paletteMode
+	lintMode
+	helpMode
+	metadataMode
+	outlineMode
+	imagePickMode
+	recentMode
+	linkCheckMode
+)
+
 type model struct {
 	width     int
 	height    int
@@ -83,21 +172,396 @@ type model struct {
 	help      help.Model
 	input     textarea.Model
 	viewport  viewport.Model
-	focus     int
 	stopwatch stopwatch.Model
 	title     string
 	filePath  string
+	theme     string
+	mode      mode
+
+	// timeSpent is the writing time recorded in the file's front matter by
+	// earlier sessions. The stopwatch only counts the current one. noTimer
+	// hides the stopwatch and never starts it.
+	timeSpent time.Duration
+	noTimer   bool
+
+	// noAutopair stops brackets and quotes being closed as they're typed.
+	noAutopair bool
+
+	// saveOnBlur saves changes when the terminal loses focus.
+	saveOnBlur bool
+
+	// smartypants typesets quotes, dashes and ellipses in the preview.
+	smartypants bool
+
+	// unwrapPaste joins the hard-wrapped lines of pasted paragraphs. pasted
+	// collects the text of a paste while pasting.
+	unwrapPaste bool
+	pasting     bool
+	pasted      []rune
+
+	// codeStyle is the Chroma style code blocks are highlighted with in the
+	// preview, or empty to use the theme's.
+	codeStyle string
+
+	// customStyle is the glamour style loaded with -style-file, used in
+	// place of the theme when it's set.
+	customStyle *ansi.StyleConfig
+
+	// frontMatter is the format front matter is written in on save, and
+	// metadata the keys read from the file's existing front matter.
+	frontMatter string
+	metadata    orderedMap
+
+	// metadataOpen expands the front matter panel, whose fields are
+	// navigated with metadataCursor and edited in metadataInput while
+	// metadataEditing. metadataEdited is set once they differ from the file.
+	metadataOpen    bool
+	metadataCursor  int
+	metadataEditing bool
+	metadataInput   textinput.Model
+	metadataEdited  bool
+
+	// outputPath is where saves go instead of filePath, when it's set.
+	outputPath string
+
+	// store is where the document is loaded from and saved to, with the
+	// permission files are written with.
+	store store
+
+	// readOnly shows only the preview, with editing and saving disabled.
+	readOnly bool
+
+	// indent is the number of spaces the tab key inserts, or zero to leave
+	// tab to the textarea.
+	indent int
+
+	// dateFormat is the layout dates are inserted with. Date and time
+	// insertions add the time of day after it.
+	dateFormat string
+
+	// dateField is the front matter key the document's first save is dated
+	// with, in RFC 3339. Empty leaves the document undated.
+	dateField string
+
+	// wrapWidth is the column plain text exports are wrapped at.
+	wrapWidth int
+
+	// trim strips trailing whitespace from lines when saving, keeping two
+	// space hard line breaks if keepHardBreaks is set.
+	trim           bool
+	keepHardBreaks bool
+
+	// lineEnding is the line ending the file is saved with, the one it
+	// mostly used when it was loaded. bom is set when it started with a
+	// byte order mark, which is kept out of the editor and written back on
+	// save.
+	lineEnding string
+	bom        bool
+
+	// goal is the word count being aimed for, shown with a progress bar in
+	// the status bar. Zero means there's no goal.
+	goal int
+
+	// expandEmoji replaces emoji shortcodes with the emoji themselves when
+	// saving.
+	expandEmoji bool
+
+	// modTime is the file's modification time as of the last load or save.
+	// externalChange is set when it has since changed under unsaved edits.
+	modTime        time.Time
+	externalChange bool
+
+	// vim enables modal editing. vimPending holds the start of a multi-key
+	// command such as dd, and vimCommand the text of a : command line.
+	vim              bool
+	vimState         vimState
+	vimPending       string
+	vimCommand       string
+	vimCommandActive bool
+
+	// savedContent is the markdown body as of the last load or save, used to
+	// work out whether the buffer is dirty.
+	savedContent string
+	dirty        bool
+
+	// stats are the word count and changes since the last save, kept up
+	// to date by updateStats.
+	stats docStats
+
+	// status is a transient message shown in place of the help bar. statusID
+	// lets a pending clearStatusMsg tell whether it's still the latest one.
+	status    string
+	statusErr bool
+	statusID  int
+
+	// Insert menu state. menuCursor is the highlighted entry in components
+	// and promptInputs collect link text and URL for prompting components.
+	menuCursor   int
+	promptInputs []textinput.Model
+	promptFocus  int
+
+	// images are the files listed while in imagePickMode, found in
+	// assetsDir or next to the document.
+	images      []string
+	imageCursor int
+	assetsDir   string
+
+	// recentFiles are the recently opened files offered by the start
+	// screen in recentMode, below a new file at recentCursor zero.
+	recentFiles  []string
+	recentCursor int
+
+	// folds are the sections of the active buffer collapsed under their
+	// headings, in order.
+	folds []fold
+
+	// cursors are where the cursor was left in files by earlier sessions,
+	// by absolute path.
+	cursors map[string]position
+
+	// titleInput edits the document title while in renameMode, and
+	// pathInput asks where to save a buffer that has no file yet.
+	titleInput textinput.Model
+	pathInput  textinput.Model
+
+	// paletteInput filters the command palette. The highlighted command is
+	// tracked with menuCursor.
+	paletteInput textinput.Model
+
+	// outlineInput filters the headings listed while in outlineMode, which
+	// are kept in headings.
+	outlineInput textinput.Model
+
+	// undoStack and redoStack hold editor snapshots, most recent last.
+	// lastEdit is when the buffer last changed, used to group bursts of
+	// typing into a single undo step.
+	undoStack []snapshot
+	redoStack []snapshot
+	lastEdit  time.Time
+
+	// headings are listed in the table of contents while in tocMode, and
+	// in the outline while in outlineMode.
+	headings  []heading
+	tocCursor int
+
+	// lintIssues are the problems found in the document when the preview
+	// was last rendered, listed while in lintMode.
+	lintIssues []lintIssue
+	lintCursor int
+
+	// links are the links found in the document by the last link check,
+	// listed while in linkCheckMode. linkCheckID tells its results apart
+	// from those of earlier checks.
+	links       []checkedLink
+	linkCursor  int
+	linkCheckID int
+
+	// Search state. matches are the hits for searchQuery and matchIndex the
+	// one the cursor was last moved to. replacement is what matches are
+	// replaced with, and replacing whether one was given for this search,
+	// which r and R need. searchRegex has the query read as a regular
+	// expression.
+	searchInput         textinput.Model
+	replaceInput        textinput.Model
+	searchQuery         string
+	replacement         string
+	replacing           bool
+	searchCaseSensitive bool
+	searchRegex         bool
+	matches             []match
+	matchIndex          int
+
+	// previewSearch is the search highlighted in the preview, compiled
+	// from previewPattern. It's nil when there's nothing to highlight.
+	previewSearch  *regexp.Regexp
+	previewPattern string
+
+	// previewSource is the markdown last rendered into the viewport,
+	// previewContent what it rendered to and previewLines its line count.
+	// previewBlocks are the rows each of its blocks was rendered onto, as
+	// worked out with blockCache.
+	previewSource  string
+	previewContent string
+	previewLines   int
+	previewBlocks  []blockRows
+	blockCache     *blockCache
+
+	// previewWidth caps the column the preview is wrapped at, and
+	// previewWrap is the column it was last rendered wrapped at.
+	previewWidth int
+	previewWrap  int
+
+	// ruler is the column a guide is drawn after in the editor, with
+	// anything past it highlighted. Zero means there's no ruler.
+	ruler int
+
+	// previewRows are the lines in the viewport, gutter included, and
+	// previewRowWidths how wide each is without trailing padding.
+	// previewXOffset is how far they're scrolled right.
+	previewRows      []string
+	previewRowWidths []int
+	previewXOffset   int
+
+	// previewPending is the markdown waiting to be rendered once typing
+	// pauses, and previewRenderID identifies the latest scheduled render.
+	previewPending  string
+	previewRenderID int
+
+	// previewBlock is the source line range of the block highlighted in
+	// the preview, valid while previewHighlighted is set.
+	previewBlock       [2]int
+	previewHighlighted bool
+
+	// previewCollapsed is set while the preview is hidden because the
+	// terminal is too narrow for it, to bring it back once there's room.
+	previewVisible   bool
+	previewCollapsed bool
+	splitRatio       float64
+	layout           layout
+
+	// wrap soft-wraps long lines in the editor. When it's off the editor
+	// scrolls horizontally instead.
+	wrap bool
+
+	// zen hides everything but the editor, centered on its own.
+	zen bool
+
+	// hideHelp hides the help bar at the bottom of the screen.
+	hideHelp bool
+
+	// autosave is the interval between automatic saves, or zero when
+	// autosaving is disabled. autosavedAt is when the last one happened.
+	autosave    time.Duration
+	autosavedAt time.Time
+
+	// saving is set while a save runs in the background, with spinner
+	// shown in the status bar. quitAfterSave quits once it succeeds.
+	saving        bool
+	spinner       spinner.Model
+	quitAfterSave bool
+
+	// exporting is set while a PDF export runs in the background, sharing
+	// the spinner with saves.
+	exporting bool
+
+	// tempFiles are the HTML files written for the browser to open, which
+	// are removed on quit. The browser may not have read them any sooner.
+	tempFiles []string
+
+	// buffers holds every open file, and active is the index of the one
+	// being edited. See buffer for which state is kept per file.
+	buffers []buffer
+	active  int
+
+	// scratch is the scratch buffer while it's put away, and scratchActive
+	// is set while it's being edited in place of the active buffer.
+	scratch       buffer
+	scratchActive bool
+}
+
+// options are the command line settings a model is created with.
+type options struct {
+	filePaths   []string
+	outputPath  string
+	content     string
+	theme       string
+	customStyle *ansi.StyleConfig
+	codeStyle   string
+	noTimer     bool
+	noAutopair  bool
+	saveOnBlur  bool
+	unwrapPaste bool
+	smartypants bool
+	autosave    time.Duration
+	frontMatter string
+	vim         bool
+	fileMode    os.FileMode
+	backups     int
+	readOnly    bool
+	indent      int
+	dateFormat  string
+	dateField   string
+
+	trim           bool
+	keepHardBreaks bool
+	expandEmoji    bool
+	wrapWidth      int
+	goal           int
+	previewWidth   int
+	ruler          int
+	assetsDir      string
+
+	// line and col are where the cursor starts out, counting from one. A
+	// line of zero leaves it where loading put it.
+	line, col int
+
+	// template is the content new files start out with.
+	template *template.Template
+
+	// keys overrides the default keys of actions, by action name.
+	keys map[string][]string
+
+	// state is the view left by the last session, if there was one.
+	state *state
+
+	// recent are the recently opened files, offered on a start screen when
+	// there's neither a file nor content to open.
+	recent []string
 }
 
-func newModel(filePath string) model {
+type autosaveMsg struct{}
+
+type clearStatusMsg struct {
+	id int
+}
+
+func newModel(opts options) model {
 	m := model{
-		input:     newTextarea(),
-		viewport:  viewport.New(0, 0),
-		help:      help.New(),
-		title:     "A New File",
-		stopwatch: stopwatch.NewWithInterval(time.Second),
-		filePath:  filePath,
+		input:       newTextarea(),
+		viewport:    viewport.New(0, 0),
+		help:        help.New(),
+		title:       defaultTitle,
+		stopwatch:   stopwatch.NewWithInterval(time.Second),
+		spinner:     newSpinner(),
+		theme:       opts.theme,
+		codeStyle:   opts.codeStyle,
+		customStyle: opts.customStyle,
+		noTimer:     opts.noTimer,
+		noAutopair:  opts.noAutopair,
+		saveOnBlur:  opts.saveOnBlur,
+		unwrapPaste: opts.unwrapPaste,
+		smartypants: opts.smartypants,
+		autosave:    opts.autosave,
+
+		frontMatter:    opts.frontMatter,
+		vim:            opts.vim,
+		store:          fileStore{mode: opts.fileMode, backups: opts.backups},
+		outputPath:     opts.outputPath,
+		readOnly:       opts.readOnly,
+		indent:         opts.indent,
+		dateFormat:     opts.dateFormat,
+		dateField:      opts.dateField,
+		trim:           opts.trim,
+		keepHardBreaks: opts.keepHardBreaks,
+		expandEmoji:    opts.expandEmoji,
+		wrapWidth:      opts.wrapWidth,
+		goal:           opts.goal,
+		previewWidth:   opts.previewWidth,
+		ruler:          opts.ruler,
+		assetsDir:      opts.assetsDir,
+		previewVisible: true,
+		splitRatio:     defaultSplitRatio,
+		wrap:           true,
 		keymap: keymap{
+			// There are only two panes, so both move focus to the other.
+			next: key.NewBinding(
+				key.WithKeys("tab"),
+				key.WithHelp("tab", "switch pane"),
+			),
+			prev: key.NewBinding(
+				key.WithKeys("shift+tab"),
+				key.WithHelp("shift+tab", "switch pane"),
+			),
 			quit: key.NewBinding(
 				key.WithKeys("esc", "ctrl+c", "cmd+q"),
 				key.WithHelp("esc", "quit"),
@@ -106,47 +570,633 @@ func newModel(filePath string) model {
 				key.WithKeys("ctrl+s", "cmd+s"),
 				key.WithHelp("ctrl+s", "save a file"),
 			),
+			saveAndQuit: key.NewBinding(
+				key.WithKeys("ctrl+x"),
+				key.WithHelp("ctrl+x", "save & quit"),
+			),
 			insertComponent: key.NewBinding(
-				key.WithKeys("ctrl+i", "cmd+i"),
-				key.WithHelp("ctrl+i", "insert md component"),
+				// Terminals send ctrl+i as tab, so alt+i is the binding
+				// that actually reaches us.
+				key.WithKeys("ctrl+i", "alt+i", "cmd+i"),
+				key.WithHelp("alt+i", "insert md component"),
+			),
+			togglePreview: key.NewBinding(
+				key.WithKeys("ctrl+p"),
+				key.WithHelp("ctrl+p", "toggle preview"),
+			),
+			rename: key.NewBinding(
+				key.WithKeys("ctrl+r"),
+				key.WithHelp("ctrl+r", "rename"),
+			),
+			undo: key.NewBinding(
+				key.WithKeys("ctrl+z"),
+				key.WithHelp("ctrl+z", "undo"),
+			),
+			redo: key.NewBinding(
+				key.WithKeys("ctrl+y"),
+				key.WithHelp("ctrl+y", "redo"),
+			),
+			toc: key.NewBinding(
+				key.WithKeys("ctrl+t"),
+				key.WithHelp("ctrl+t", "table of contents"),
+			),
+			outline: key.NewBinding(
+				key.WithKeys("ctrl+o"),
+				key.WithHelp("ctrl+o", "go to heading"),
+			),
+			exportHTML: key.NewBinding(
+				key.WithKeys("ctrl+e"),
+				key.WithHelp("ctrl+e", "export html"),
+			),
+			exportPDF: key.NewBinding(
+				key.WithKeys("f4"),
+				key.WithHelp("f4", "export pdf"),
+			),
+			exportText: key.NewBinding(
+				key.WithKeys("alt+e"),
+				key.WithHelp("alt+e", "export text"),
+			),
+			openBrowser: key.NewBinding(
+				key.WithKeys("alt+o"),
+				key.WithHelp("alt+o", "open in browser"),
+			),
+			copyMarkdown: key.NewBinding(
+				key.WithKeys("alt+c"),
+				key.WithHelp("alt+c", "copy markdown"),
+			),
+			copyHTML: key.NewBinding(
+				key.WithKeys("alt+h"),
+				key.WithHelp("alt+h", "copy html"),
+			),
+			toggleLayout: key.NewBinding(
+				key.WithKeys("alt+l"),
+				key.WithHelp("alt+l", "stack/split panes"),
+			),
+			shrinkEditor: key.NewBinding(
+				key.WithKeys("ctrl+left"),
+				key.WithHelp("ctrl+←", "shrink editor"),
+			),
+			growEditor: key.NewBinding(
+				key.WithKeys("ctrl+right"),
+				key.WithHelp("ctrl+→", "grow editor"),
+			),
+			search: key.NewBinding(
+				key.WithKeys("ctrl+f"),
+				key.WithHelp("ctrl+f", "search"),
+			),
+			toggleWrap: key.NewBinding(
+				key.WithKeys("alt+w"),
+				key.WithHelp("alt+w", "toggle wrap"),
+			),
+			palette: key.NewBinding(
+				key.WithKeys("ctrl+k"),
+				key.WithHelp("ctrl+k", "commands"),
+			),
+			// Terminals don't report ctrl+tab, so buffers are cycled
+			// with alt+n and alt+p instead.
+			nextBuffer: key.NewBinding(
+				key.WithKeys("alt+n"),
+				key.WithHelp("alt+n", "next file"),
+			),
+			prevBuffer: key.NewBinding(
+				key.WithKeys("alt+p"),
+				key.WithHelp("alt+p", "previous file"),
+			),
+			toggleScratch: key.NewBinding(
+				key.WithKeys("alt+s"),
+				key.WithHelp("alt+s", "scratch buffer"),
+			),
+			insertDate: key.NewBinding(
+				key.WithKeys("f5"),
+				key.WithHelp("f5", "insert date"),
+			),
+			insertDateTime: key.NewBinding(
+				key.WithKeys("f6"),
+				key.WithHelp("f6", "insert date and time"),
+			),
+			pauseTimer: key.NewBinding(
+				key.WithKeys("ctrl+w"),
+				key.WithHelp("ctrl+w", "pause timer"),
+			),
+			lint: key.NewBinding(
+				key.WithKeys("ctrl+l"),
+				key.WithHelp("ctrl+l", "problems"),
+			),
+			checkLinks: key.NewBinding(
+				key.WithKeys("f3"),
+				key.WithHelp("f3", "check links"),
+			),
+			bold: key.NewBinding(
+				key.WithKeys("ctrl+b"),
+				key.WithHelp("ctrl+b", "bold"),
+			),
+			// Terminals send ctrl+/ as ctrl+_.
+			italic: key.NewBinding(
+				key.WithKeys("ctrl+_"),
+				key.WithHelp("ctrl+/", "italic"),
+			),
+			code: key.NewBinding(
+				key.WithKeys("alt+`"),
+				key.WithHelp("alt+`", "inline code"),
+			),
+			// Terminals send ctrl+space as ctrl+@.
+			toggleTask: key.NewBinding(
+				key.WithKeys("ctrl+@"),
+				key.WithHelp("ctrl+space", "toggle task"),
+			),
+			promoteHeading: key.NewBinding(
+				key.WithKeys("alt+up"),
+				key.WithHelp("alt+↑", "promote heading"),
+			),
+			toggleFold: key.NewBinding(
+				key.WithKeys("alt+z"),
+				key.WithHelp("alt+z", "fold/unfold section"),
+			),
+			demoteHeading: key.NewBinding(
+				key.WithKeys("alt+down"),
+				key.WithHelp("alt+↓", "demote heading"),
+			),
+			formatTable: key.NewBinding(
+				key.WithKeys("alt+t"),
+				key.WithHelp("alt+t", "format table"),
+			),
+			metadata: key.NewBinding(
+				key.WithKeys("alt+m"),
+				key.WithHelp("alt+m", "front matter"),
+			),
+			pasteLink: key.NewBinding(
+				key.WithKeys("alt+v"),
+				key.WithHelp("alt+v", "paste as link"),
+			),
+			// ? can't be used while it types a question mark.
+			showHelp: key.NewBinding(
+				key.WithKeys("f1"),
+				key.WithHelp("f1", "help"),
+			),
+			toggleHelpBar: key.NewBinding(
+				key.WithKeys("f2"),
+				key.WithHelp("f2", "hide help bar"),
+			),
+			zen: key.NewBinding(
+				key.WithKeys("ctrl+g"),
+				key.WithHelp("ctrl+g", "zen mode"),
 			),
 		},
 	}
 
+	bindKeys(&m.keymap, opts.keys)
+
+	if opts.state != nil {
+		m.restoreState(*opts.state)
+	}
+
+	if opts.content != "" || len(opts.filePaths) == 0 {
+		m.buffers = make([]buffer, 1)
+		m.setContent(opts.content)
+		// Piped in content hasn't been saved anywhere yet.
+		m.savedContent = ""
+		m.readTimeSpent()
+	} else {
+		m.buffers = make([]buffer, len(opts.filePaths))
+		for i, path := range opts.filePaths {
+			m.buffers[i] = buffer{input: newTextarea(), filePath: path, title: defaultTitle}
+			m.restoreBuffer(i)
+			if err := m.load(); err != nil {
+				// A file that doesn't exist yet is a new one, so start out
+				// from the template if there is one.
+				var content string
+				if errors.Is(err, os.ErrNotExist) {
+					content = newFileContent(opts.template, path, opts.dateFormat)
+				}
+				m.setContent(content)
+				m.savedContent = ""
+			} else {
+				m.restoreCursor()
+			}
+			m.readTimeSpent()
+			m.stashBuffer()
+		}
+		m.restoreBuffer(0)
+	}
+	m.applyDirectives()
+
+	if opts.content == "" && len(opts.filePaths) == 0 {
+		m.mode = recentMode
+		m.recentFiles = opts.recent
+	}
+
+	// The editor starts out with focus, rather than the preview.
+	if !m.readOnly {
+		m.input.Focus()
+	}
+
+	// -line wins over where the cursor was left last time.
+	if opts.line > 0 && !m.readOnly {
+		moveCursor(&m.input, opts.line-1, opts.col-1)
+		m.scrollToCursor()
+	}
+
 	m.updateKeybindings()
+	m.updateStats()
 	return m
 }
 
+// readTimeSpent carries on from the time already spent on the document. It's
+// read only when a file is opened, since reloads pick up time this session
+// already counts.
+func (m *model) readTimeSpent() {
+	m.timeSpent = 0
+	if spent, ok := m.metadata.Get("time"); ok {
+		if d, err := time.ParseDuration(spent); err == nil {
+			m.timeSpent = d
+		}
+	}
+}
+
+// applyDirectives applies the editor settings the document carries in its
+// front matter, under keys reserved for them: markaway_theme for the preview
+// theme, markaway_wrap for soft-wrapping and markaway_goal for the word
+// goal. Values that don't make sense are ignored. With several files open,
+// the first one's settings are used.
+func (m *model) applyDirectives() {
+	if theme, ok := m.metadata.Get("markaway_theme"); ok && validTheme(theme) {
+		m.theme = theme
+	}
+	if v, ok := m.metadata.Get("markaway_wrap"); ok {
+		if wrap, err := strconv.ParseBool(v); err == nil {
+			m.wrap = wrap
+		}
+	}
+	if v, ok := m.metadata.Get("markaway_goal"); ok {
+		if goal, err := strconv.Atoi(v); err == nil && goal >= 0 {
+			m.goal = goal
+		}
+	}
+}
+
 func (m model) Init() tea.Cmd {
+	var timer tea.Cmd
+	if !m.noTimer {
+		timer = m.stopwatch.Init()
+	}
+
 	return tea.Batch(
 		textarea.Blink,
-		m.stopwatch.Init(),
+		timer,
+		m.scheduleAutosave(),
+		watchFile(),
 	)
 }
 
+// scheduleAutosave returns a command that fires the next autosave, or nil
+// if autosaving is disabled.
+func (m model) scheduleAutosave() tea.Cmd {
+	if m.autosave <= 0 {
+		return nil
+	}
+
+	return tea.Tick(m.autosave, func(time.Time) tea.Msg {
+		return autosaveMsg{}
+	})
+}
+
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	row, lines := m.input.Line(), m.input.LineCount()
+	m, cmd := m.update(msg)
+	m.adjustFolds(row, lines)
+	m.syncPreviewSearch()
+
+	m.dirty = m.isDirty()
+	m.updateStats()
+
+	return m, tea.Batch(cmd, m.updatePreview())
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if isFocusKey(msg) {
+			if msg.String() == focusOutKey {
+				return m, m.blurred()
+			}
+			return m, nil
+		}
+		if m.saving {
+			return m, nil
+		}
+		if m.unwrapPaste {
+			var (
+				cmd     tea.Cmd
+				handled bool
+			)
+			if m, cmd, handled = m.updatePaste(msg); handled {
+				return m, cmd
+			}
+		}
+
+		switch m.mode {
+		case confirmQuitMode:
+			switch msg.String() {
+			case "y", "Y":
+				m.input.Blur()
+				return m, tea.Quit
+			case "n", "N", "esc":
+				m.mode = editMode
+			}
+			return m, nil
+		case insertMenuMode:
+			m, cmd := m.updateInsertMenu(msg)
+			return m, cmd
+		case componentPromptMode:
+			m, cmd := m.updateComponentPrompt(msg)
+			return m, cmd
+		case imagePickMode:
+			m, cmd := m.updateImagePicker(msg)
+			return m, cmd
+		case recentMode:
+			m, cmd := m.updateRecent(msg)
+			return m, cmd
+		case tocMode:
+			m, cmd := m.updateTOC(msg)
+			return m, cmd
+		case lintMode:
+			m, cmd := m.updateLint(msg)
+			return m, cmd
+		case linkCheckMode:
+			m, cmd := m.updateLinkCheck(msg)
+			return m, cmd
+		case metadataMode:
+			m, cmd := m.updateMetadata(msg)
+			return m, cmd
+		case helpMode:
+			if msg.String() == "esc" || key.Matches(msg, m.keymap.showHelp) {
+				m.mode = editMode
+			}
+			return m, nil
+		case searchMode:
+			m, cmd := m.updateSearch(msg)
+			return m, cmd
+		case matchMode:
+			var (
+				cmd     tea.Cmd
+				handled bool
+			)
+			if m, cmd, handled = m.updateMatches(msg); handled {
+				return m, cmd
+			}
+		case savePathMode:
+			m, cmd := m.updateSavePath(msg)
+			return m, cmd
+		case paletteMode:
+			m, cmd := m.updatePalette(msg)
+			return m, cmd
+		case outlineMode:
+			m, cmd := m.updateOutline(msg)
+			return m, cmd
+		case renameMode:
+			switch msg.String() {
+			case "enter":
+				if title := strings.TrimSpace(m.titleInput.Value()); title != "" {
+					m.title = title
+				}
+				m.mode = editMode
+				return m, m.input.Focus()
+			case "esc":
+				m.mode = editMode
+				return m, m.input.Focus()
+			}
+
+			var cmd tea.Cmd
+			m.titleInput, cmd = m.titleInput.Update(msg)
+			return m, cmd
+		}
+
+		if m.externalChange {
+			var (
+				cmd     tea.Cmd
+				handled bool
+			)
+			if m, cmd, handled = m.updateExternalChange(msg); handled {
+				return m, cmd
+			}
+		}
+
+		if m.vim && !m.readOnly {
+			var (
+				cmd     tea.Cmd
+				handled bool
+			)
+			if m, cmd, handled = m.updateVim(msg); handled {
+				return m, cmd
+			}
+		}
+
+		if !m.noAutopair && m.input.Focused() && !m.readOnly && !(m.vim && m.vimState == vimNormal) {
+			before := m.snapshot()
+			if m.autopair(msg) {
+				m.recordEdit(before)
+				return m, nil
+			}
+		}
+
 		switch {
+		case msg.Type == tea.KeyTab && m.indent > 0 && m.input.Focused() && !(m.vim && m.vimState == vimNormal):
+			before := m.snapshot()
+			m.input.InsertString(strings.Repeat(" ", m.indent))
+			m.recordEdit(before)
+			return m, nil
+		case msg.Type == tea.KeyEnter && m.input.Focused() && !m.readOnly && !(m.vim && m.vimState == vimNormal):
+			before := m.snapshot()
+			if m.continueList() {
+				m.recordEdit(before)
+				return m, nil
+			}
+		case key.Matches(msg, m.keymap.next), key.Matches(msg, m.keymap.prev):
+			return m, m.switchFocus()
 		case key.Matches(msg, m.keymap.quit):
-			m.input.Blur()
-			return m, tea.Quit
+			return m, m.quit()
+		case key.Matches(msg, m.keymap.saveAndQuit):
+			return m, m.saveAndQuit()
 		case key.Matches(msg, m.keymap.save):
-			saveFile(m)
+			cmds = append(cmds, m.save())
+		case key.Matches(msg, m.keymap.insertComponent):
+			m.openInsertMenu()
+			return m, nil
+		case key.Matches(msg, m.keymap.rename):
+			return m, m.startRename()
+		case key.Matches(msg, m.keymap.undo):
+			m.undo()
+			return m, nil
+		case key.Matches(msg, m.keymap.redo):
+			m.redo()
+			return m, nil
+		case key.Matches(msg, m.keymap.toc):
+			m.openTOC()
+			return m, nil
+		case key.Matches(msg, m.keymap.outline):
+			return m, m.openOutline()
+		case key.Matches(msg, m.keymap.exportHTML):
+			return m, m.exportHTMLFile()
+		case key.Matches(msg, m.keymap.exportText):
+			return m, m.exportTextFile()
+		case key.Matches(msg, m.keymap.exportPDF):
+			return m, m.exportPDFFile()
+		case key.Matches(msg, m.keymap.openBrowser):
+			return m, m.openBrowser()
+		case key.Matches(msg, m.keymap.copyMarkdown):
+			return m, m.copyMarkdown()
+		case key.Matches(msg, m.keymap.copyHTML):
+			return m, m.copyHTML()
+		case key.Matches(msg, m.keymap.toggleLayout):
+			return m, m.toggleLayout()
+		case key.Matches(msg, m.keymap.shrinkEditor):
+			m.adjustSplit(-splitRatioStep)
+			return m, nil
+		case key.Matches(msg, m.keymap.growEditor):
+			m.adjustSplit(splitRatioStep)
+			return m, nil
+		case key.Matches(msg, m.keymap.search):
+			return m, m.openSearch()
+		case key.Matches(msg, m.keymap.pauseTimer):
+			return m, m.stopwatch.Toggle()
+		case key.Matches(msg, m.keymap.bold):
+			m.checkpoint()
+			m.insertComponent(boldMarkers)
+			return m, nil
+		case key.Matches(msg, m.keymap.italic):
+			m.checkpoint()
+			m.insertComponent(italicMarkers)
+			return m, nil
+		case key.Matches(msg, m.keymap.code):
+			m.checkpoint()
+			m.insertComponent(codeMarkers)
+			return m, nil
+		case key.Matches(msg, m.keymap.toggleTask):
+			m.toggleTask()
+			return m, nil
+		case key.Matches(msg, m.keymap.promoteHeading):
+			m.shiftHeading(-1)
+			return m, nil
+		case key.Matches(msg, m.keymap.demoteHeading):
+			m.shiftHeading(1)
+			return m, nil
+		case key.Matches(msg, m.keymap.toggleFold):
+			return m, m.toggleFold()
+		case key.Matches(msg, m.keymap.formatTable):
+			return m, m.formatTable()
+		case key.Matches(msg, m.keymap.metadata):
+			return m, m.toggleMetadata()
+		case key.Matches(msg, m.keymap.pasteLink):
+			return m, m.pasteLink()
+		case key.Matches(msg, m.keymap.showHelp):
+			m.mode = helpMode
+			return m, nil
+		case key.Matches(msg, m.keymap.toggleHelpBar):
+			return m, m.toggleHelpBar()
+		case key.Matches(msg, m.keymap.lint):
+			m.openLint()
+			return m, nil
+		case key.Matches(msg, m.keymap.checkLinks):
+			return m, m.openLinkCheck()
+		case key.Matches(msg, m.keymap.zen):
+			m.toggleZen()
+			return m, nil
+		case key.Matches(msg, m.keymap.toggleWrap):
+			m.toggleWrap()
+			return m, nil
+		case key.Matches(msg, m.keymap.togglePreview):
+			return m, m.togglePreview()
+		case key.Matches(msg, m.keymap.palette):
+			return m, m.openPalette()
+		case key.Matches(msg, m.keymap.insertDate):
+			m.insertTime(m.dateFormat)
+			return m, nil
+		case key.Matches(msg, m.keymap.insertDateTime):
+			m.insertTime(m.dateFormat + " " + timeOfDayFormat)
+			return m, nil
+		case m.previewFocused() && (msg.String() == "left" || msg.String() == "right"):
+			delta := previewScrollColumns
+			if msg.String() == "left" {
+				delta = -delta
+			}
+			m.scrollPreviewX(delta)
+			return m, nil
+		case key.Matches(msg, m.keymap.nextBuffer):
+			m.switchBuffer(1)
+			return m, nil
+		case key.Matches(msg, m.keymap.prevBuffer):
+			m.switchBuffer(-1)
+			return m, nil
+		case key.Matches(msg, m.keymap.toggleScratch):
+			return m, m.toggleScratch()
+		case m.previewFocused() && isScrollKey(msg):
+			// Left to the viewport below.
 		default:
-			if !m.input.Focused() {
+			if !m.input.Focused() && !m.readOnly {
 				cmd := m.input.Focus()
 				cmds = append(cmds, cmd)
 
 			}
 		}
 
+	case tea.MouseMsg:
+		return m.updateMouse(msg)
+
 	case tea.WindowSizeMsg:
 		m.height = msg.Height
 		m.width = msg.Width
-		m.sizeInputs()
+		cmds = append(cmds, m.fitLayout())
+		// The textarea only scrolls to its cursor while handling input, so
+		// a cursor restored further down wouldn't be in view yet.
+		if m.input.Focused() {
+			m.scrollToCursor()
+		}
+
+	case autosaveMsg:
+		if m.dirty && m.savePath() != "" && !m.saving {
+			cmds = append(cmds, m.startSave(autoSave))
+		}
+		cmds = append(cmds, m.scheduleAutosave())
+
+	case saveDoneMsg:
+		return m, m.finishSave(msg)
+
+	case linkTitleMsg:
+		return m, m.insertLink(msg)
+
+	case linkStatusMsg:
+		m.finishLinkCheck(msg)
+		return m, nil
+
+	case pdfDoneMsg:
+		return m, m.finishPDFExport(msg)
+
+	case spinner.TickMsg:
+		if m.saving || m.exporting {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+
+	case previewRenderMsg:
+		if msg.id == m.previewRenderID {
+			m.previewPending = ""
+			m.renderPreview()
+			if !m.readOnly {
+				m.syncPreviewScroll()
+			}
+		}
+
+	case fileWatchMsg:
+		cmds = append(cmds, m.checkFile(), watchFile())
+
+	case clearStatusMsg:
+		if msg.id == m.statusID {
+			m.status = ""
+		}
 	}
 
 	m.updateKeybindings()
@@ -156,24 +1206,385 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		vpCmd tea.Cmd
 		swCmd tea.Cmd
 	)
+	before := m.snapshot()
 	m.input, tiCmd = m.input.Update(msg)
+	if m.input.Value() != before.value {
+		m.recordEdit(before)
+	}
 	m.viewport, vpCmd = m.viewport.Update(msg)
 	m.stopwatch, swCmd = m.stopwatch.Update(msg)
 
+	if _, ok := msg.(tea.KeyMsg); ok && !m.readOnly && !m.previewFocused() {
+		m.syncPreviewScroll()
+	}
+
 	cmds = append(cmds, tiCmd, vpCmd, swCmd)
 	return m, tea.Batch(cmds...)
 }
 
+// isDirty reports whether the buffer or its front matter have changed since
+// they were last loaded or saved.
+// The scratch buffer is never saved, so it's never dirty either.
+func (m model) isDirty() bool {
+	if m.scratchActive {
+		return false
+	}
+	return m.input.Value() != m.savedContent || m.metadataEdited
+}
+
+// savePath is the path the buffer is saved to.
+func (m model) savePath() string {
+	if m.outputPath != "" {
+		return m.outputPath
+	}
+	return m.filePath
+}
+
+// save writes the buffer to disk and reports the outcome in the status bar.
+// A buffer without a file asks for a path to save to first.
+func (m *model) save() tea.Cmd {
+	if m.scratchActive {
+		m.quitAfterSave = false
+		return m.setStatus("The scratch buffer can't be saved", true)
+	}
+	if m.savePath() == "" {
+		m.mode = savePathMode
+		m.pathInput = textinput.New()
+		m.pathInput.Prompt = "Save as: "
+		m.pathInput.Placeholder = "path/to/file.md"
+		m.input.Blur()
+		return m.pathInput.Focus()
+	}
+
+	return m.startSave(manualSave)
+}
+
+func (m model) updateSavePath(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = editMode
+		m.quitAfterSave = false
+		return m, m.input.Focus()
+	case "enter":
+		path := strings.TrimSpace(m.pathInput.Value())
+		if path == "" {
+			m.mode = editMode
+			m.quitAfterSave = false
+			return m, m.input.Focus()
+		}
+		if err := checkFilePath(path); err != nil {
+			return m, m.setStatus(err.Error(), true)
+		}
+
+		m.filePath = path
+		m.mode = editMode
+		return m, tea.Batch(m.input.Focus(), m.save())
+	}
+
+	var cmd tea.Cmd
+	m.pathInput, cmd = m.pathInput.Update(msg)
+	return m, cmd
+}
+
+// quit exits the program, asking for confirmation first if there are
+// unsaved changes.
+func (m *model) quit() tea.Cmd {
+	if m.anyDirty() {
+		m.mode = confirmQuitMode
+		return nil
+	}
+
+	m.input.Blur()
+	return tea.Quit
+}
+
+// startRename switches to editing the title in place.
+func (m *model) startRename() tea.Cmd {
+	m.mode = renameMode
+	m.titleInput = textinput.New()
+	m.titleInput.Prompt = ""
+	m.titleInput.SetValue(m.title)
+	m.input.Blur()
+	return m.titleInput.Focus()
+}
+
+// exportHTMLFile exports the document as HTML next to the file and reports
+// where it went.
+func (m *model) exportHTMLFile() tea.Cmd {
+	path, err := exportHTML(*m)
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	return m.setStatus("Exported HTML to "+path, false)
+}
+
+// openBrowser shows the document rendered as HTML in the default browser.
+func (m *model) openBrowser() tea.Cmd {
+	path, err := exportTempHTML(*m)
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	// The opener can take a while to hand over to the browser, so it's
+	// left to finish in the background.
+	cmd := openCommand(path)
+	if err := cmd.Start(); err != nil {
+		os.Remove(path)
+		return m.setStatus("Could not open a browser: "+err.Error(), true)
+	}
+	go cmd.Wait()
+	m.tempFiles = append(m.tempFiles, path)
+	return m.setStatus("Opened "+path, false)
+}
+
+// insertTime inserts the current time at the cursor, formatted with layout.
+func (m *model) insertTime(layout string) {
+	m.checkpoint()
+	m.input.InsertString(time.Now().Format(layout))
+}
+
+// exportTextFile exports the document as plain text next to the file and
+// reports where it went.
+func (m *model) exportTextFile() tea.Cmd {
+	path, err := exportText(*m)
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	return m.setStatus("Exported text to "+path, false)
+}
+
+func (m *model) togglePreview() tea.Cmd {
+	if !m.previewVisible && !m.previewFits() {
+		return m.setStatus("terminal too small for preview", true)
+	}
+
+	m.previewVisible = !m.previewVisible
+	m.previewCollapsed = false
+	m.sizeInputs()
+	return nil
+}
+
+// toggleLayout switches between the preview beside the editor and below
+// it.
+func (m *model) toggleLayout() tea.Cmd {
+	if m.layout == horizontalLayout {
+		m.layout = verticalLayout
+	} else {
+		m.layout = horizontalLayout
+	}
+	return m.fitLayout()
+}
+
+// previewFits reports whether there's room for the preview alongside the
+// editor in the current layout.
+func (m model) previewFits() bool {
+	if m.layout == verticalLayout {
+		return m.bodyHeight() >= minStackedHeight
+	}
+	return m.width >= minPreviewWidth
+}
+
+// fitLayout collapses to the editor alone while the terminal is too small
+// for the preview alongside it, and brings the preview back once there's
+// room again.
+func (m *model) fitLayout() tea.Cmd {
+	var cmd tea.Cmd
+	fits := m.previewFits()
+	switch {
+	case !fits && m.previewVisible && !m.readOnly:
+		m.previewVisible = false
+		m.previewCollapsed = true
+		cmd = m.setStatus("terminal too small for preview", true)
+	case fits && m.previewCollapsed:
+		m.previewVisible = true
+		m.previewCollapsed = false
+	}
+
+	m.sizeInputs()
+	return cmd
+}
+
+func (m *model) toggleZen() {
+	m.zen = !m.zen
+	m.sizeInputs()
+}
+
+func (m *model) toggleWrap() {
+	m.wrap = !m.wrap
+	m.sizeInputs()
+}
+
+// elapsed is the total time spent writing the document, across sessions.
+func (m model) elapsed() time.Duration {
+	return m.timeSpent + m.stopwatch.Elapsed()
+}
+
+// setStatus shows msg in the status area and returns a command that clears it
+// again after statusTimeout. Errors are logged too, since they're gone from
+// the screen once it's cleared.
+func (m *model) setStatus(msg string, isErr bool) tea.Cmd {
+	if isErr {
+		log.Printf("error: %s", msg)
+	}
+
+	m.statusID++
+	m.status = msg
+	m.statusErr = isErr
+
+	id := m.statusID
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+		return clearStatusMsg{id: id}
+	})
+}
+
+// adjustSplit moves the split between editor and preview by delta, keeping
+// it within minSplitRatio and maxSplitRatio.
+func (m *model) adjustSplit(delta float64) {
+	ratio := math.Round((m.splitRatio+delta)*10) / 10
+	m.splitRatio = math.Max(minSplitRatio, math.Min(maxSplitRatio, ratio))
+	m.sizeInputs()
+}
+
+// editorWidth is the width of the editor pane.
+func (m model) editorWidth() int {
+	switch {
+	case m.zen:
+		if m.width < zenWidth {
+			return m.width
+		}
+		return zenWidth
+	case m.previewVisible && m.layout == horizontalLayout:
+		return int(float64(m.width) * m.splitRatio)
+	}
+	return m.width
+}
+
+// editorHeight is the height of the editor pane, which is all of the body
+// unless the preview is stacked below it.
+func (m model) editorHeight() int {
+	if !m.previewVisible || m.layout != verticalLayout {
+		return m.bodyHeight()
+	}
+
+	// Each pane has its own border, where the editor alone has the body's.
+	h := int(float64(m.bodyHeight()-focusedBorderStyle.GetVerticalFrameSize()) * m.splitRatio)
+	if h < minBodyHeight {
+		return minBodyHeight
+	}
+	return h
+}
+
+// bodyHeight is the height left for the editor and preview.
+func (m model) bodyHeight() int {
+	h := m.height - helpHeight - titleHeight - m.tabsHeight() - m.metadataHeight()
+	if m.hideHelp {
+		h += helpBarHeight
+	}
+	if h < minBodyHeight {
+		return minBodyHeight
+	}
+	return h
+}
+
 func (m *model) sizeInputs() {
-	m.input.SetWidth(m.width / 2)
-	m.input.SetHeight(m.height - helpHeight - titleHeight)
+	if m.readOnly {
+		m.viewport.Width = m.width
+		m.viewport.Height = m.bodyHeight()
+		m.viewport.SetYOffset(m.viewport.YOffset)
+		return
+	}
+
+	editorWidth := m.editorWidth()
+	if editorWidth < minEditorWidth {
+		editorWidth = minEditorWidth
+	}
+
+	// Without wrapping the textarea is made much wider than the pane and
+	// noWrapEditorView draws the border around the visible part itself.
+	if m.wrap {
+		m.input.FocusedStyle.Base = focusedBorderStyle
+		m.input.BlurredStyle.Base = blurredBorderStyle
+		m.input.SetWidth(editorWidth)
+	} else {
+		m.input.FocusedStyle.Base = lipgloss.NewStyle()
+		m.input.BlurredStyle.Base = lipgloss.NewStyle()
+		m.input.SetWidth(noWrapWidth)
+	}
+	m.fitFolds()
+	m.input.SetHeight(m.inputRows() + m.foldedLines())
+
+	if !m.previewVisible {
+		return
+	}
 
-	m.viewport = viewport.New(m.width/2, m.height-helpHeight-titleHeight)
+	// The preview has a border beside or below the editor, to show when it
+	// has focus.
+	if m.layout == verticalLayout {
+		m.viewport.Width = m.width - focusedBorderStyle.GetHorizontalFrameSize()
+		m.viewport.Height = m.bodyHeight() - focusedBorderStyle.GetVerticalFrameSize() - m.editorHeight()
+		if m.viewport.Height < minBodyHeight {
+			m.viewport.Height = minBodyHeight
+		}
+	} else {
+		m.viewport.Width = m.width - editorWidth - focusedBorderStyle.GetHorizontalFrameSize()
+		m.viewport.Height = m.bodyHeight()
+	}
+	m.viewport.SetYOffset(m.viewport.YOffset)
 }
 
 func (m *model) updateKeybindings() {
-	// m.keymap.add.SetEnabled(len(m.inputs) < maxInputs)
-	// m.keymap.remove.SetEnabled(len(m.inputs) > minInputs)
+	for _, b := range []*key.Binding{
+		&m.keymap.save,
+		&m.keymap.saveAndQuit,
+		&m.keymap.insertComponent,
+		&m.keymap.rename,
+		&m.keymap.undo,
+		&m.keymap.redo,
+		&m.keymap.togglePreview,
+		&m.keymap.toggleLayout,
+		&m.keymap.shrinkEditor,
+		&m.keymap.growEditor,
+		&m.keymap.insertDate,
+		&m.keymap.insertDateTime,
+		&m.keymap.zen,
+		&m.keymap.bold,
+		&m.keymap.italic,
+		&m.keymap.code,
+		&m.keymap.metadata,
+		&m.keymap.pasteLink,
+		&m.keymap.toggleTask,
+		&m.keymap.formatTable,
+		&m.keymap.promoteHeading,
+		&m.keymap.demoteHeading,
+		&m.keymap.toggleFold,
+		&m.keymap.toggleScratch,
+	} {
+		b.SetEnabled(!m.readOnly)
+	}
+	if m.scratchActive {
+		m.keymap.save.SetEnabled(false)
+		m.keymap.saveAndQuit.SetEnabled(false)
+	}
+
+	m.keymap.pauseTimer.SetEnabled(!m.noTimer)
+	m.keymap.next.SetEnabled(m.previewVisible && !m.zen && !m.readOnly)
+	if m.indent > 0 {
+		// Tab indents instead, which leaves shift+tab to switch panes.
+		var keys []string
+		for _, k := range m.keymap.next.Keys() {
+			if k != "tab" {
+				keys = append(keys, k)
+			}
+		}
+		m.keymap.next.SetKeys(keys...)
+		if len(keys) > 0 {
+			m.keymap.next.SetHelp(keys[0], m.keymap.next.Help().Desc)
+		} else {
+			m.keymap.next.SetEnabled(false)
+		}
+	}
+	m.keymap.prev.SetEnabled(m.previewVisible && !m.zen && !m.readOnly)
+	m.keymap.nextBuffer.SetEnabled(len(m.buffers) > 1)
+	m.keymap.prevBuffer.SetEnabled(len(m.buffers) > 1)
 }
 
 var (
@@ -183,98 +1594,782 @@ var (
 	titleStyle     = lipgloss.NewStyle().Bold(true).Align(L).Padding(1, 1)
 	bufferStyle    = lipgloss.NewStyle()
 	stopwatchStyle = lipgloss.NewStyle().Bold(true).Align(R).Padding(1, 1)
+	countStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Align(R).Padding(1, 0, 1, 1)
+	dirtyStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("212"))
 )
 
 func (m model) View() string {
+	if m.zen {
+		return m.zenView()
+	}
+
 	page := strings.Builder{}
 
-	title := titleStyle.Render(m.title)
-	sw := stopwatchStyle.Render(m.stopwatch.View())
-	buffer := bufferStyle.Width(m.width - lipgloss.Width(title) - lipgloss.Width(sw)).Render(" ")
+	titleText := m.title
+	if m.mode == renameMode {
+		titleText = m.titleInput.View()
+	}
+	if m.dirty && m.mode != renameMode {
+		titleText = dirtyStyle.Render("●") + " " + titleText
+	}
+	if m.readOnly {
+		titleText += " [read-only]"
+	}
+	if time.Since(m.autosavedAt) < statusTimeout {
+		titleText += " " + statusStyle.Render("auto-saved")
+	}
+	title := titleStyle.Render(titleText)
+	words := m.stats.words
+	counts := fmt.Sprintf("%s words · %s · %s chars",
+		formatCount(words), readingTime(words), formatCount(m.stats.chars))
+	sw := ""
+	if m.noTimer {
+		counts = countStyle.PaddingRight(1).Render(counts)
+	} else {
+		counts = countStyle.Render(counts)
+		elapsed := m.elapsed().String()
+		if !m.stopwatch.Running() {
+			elapsed += " (paused)"
+		}
+		sw = stopwatchStyle.Render(elapsed)
+	}
+	buffer := bufferStyle.Width(m.width - lipgloss.Width(title) - lipgloss.Width(counts) - lipgloss.Width(sw)).Render(" ")
 
 	titleBar := lipgloss.JoinHorizontal(
 		lipgloss.Top,
 		title,
 		buffer,
+		counts,
 		sw,
 	)
 	page.WriteString(titleBar)
 
-	help := m.help.ShortHelpView([]key.Binding{
+	// Bindings disabled by updateKeybindings are left out, and those that
+	// don't fit beside the cursor position are cut off.
+	h := m.help
+	h.Width = m.width * 2 / 3
+	help := h.ShortHelpView([]key.Binding{
 		m.keymap.next,
-		m.keymap.prev,
-		m.keymap.add,
-		m.keymap.remove,
+		m.keymap.save,
+		m.keymap.togglePreview,
+		m.keymap.search,
+		m.keymap.palette,
+		m.keymap.showHelp,
 		m.keymap.quit,
 	})
 
-	renderedMarkdown, _ := glamour.Render(m.input.Value(), "dark")
-
-	m.viewport.SetContent(renderedMarkdown)
-
 	// Need to style left and right sides
 	// 1. have a gutter between
 	// 2. Nice padding
 	// 3. Highlight current line
 
 	page.WriteString("\n\n")
-	page.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.input.View(), m.viewport.View()))
-	page.WriteString("\n\n")
-	page.WriteString(help)
-	return page.String()
+	if tabs := m.tabsView(); tabs != "" {
+		page.WriteString(tabs + "\n")
+	}
+	if metadata := m.metadataView(); metadata != "" {
+		page.WriteString(metadata + "\n")
+	}
+	if overlay := m.overlayView(); overlay != "" {
+		page.WriteString(lipgloss.Place(
+			m.width, m.bodyHeight(),
+			lipgloss.Center, lipgloss.Center,
+			overlay,
+		))
+	} else if m.readOnly {
+		page.WriteString(m.previewView())
+	} else if !m.previewVisible {
+		page.WriteString(m.editorView())
+	} else {
+		border := blurredBorderStyle
+		if m.previewFocused() {
+			border = focusedBorderStyle
+		}
+		if m.layout == verticalLayout {
+			page.WriteString(lipgloss.JoinVertical(lipgloss.Left, m.editorView(), border.Render(m.previewView())))
+		} else {
+			page.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, m.editorView(), border.Render(m.previewView())))
+		}
+	}
+	if !m.hideHelp {
+		page.WriteString("\n\n")
+		page.WriteString(m.statusBarView(help))
+		return page.String()
+	}
+
+	// Without the help bar, prompts and messages are shown over the last
+	// line of the body while there are any.
+	if !m.hasStatus() {
+		return page.String()
+	}
+	lines := strings.Split(page.String(), "\n")
+	lines[len(lines)-1] = m.statusBarView("")
+	return strings.Join(lines, "\n")
+}
+
+// hasStatus reports whether there's a prompt or message for the status
+// bar, which otherwise only shows help.
+func (m model) hasStatus() bool {
+	return m.mode != editMode || m.status != "" || m.externalChange || m.saving || m.exporting || m.vimCommandActive
+}
+
+// toggleHelpBar hides or shows the help bar, giving its rows to the body
+// while it's hidden.
+func (m *model) toggleHelpBar() tea.Cmd {
+	m.hideHelp = !m.hideHelp
+	return m.fitLayout()
+}
+
+// zenView renders the editor alone, centered, with the status bar showing
+// only while there's a prompt or message.
+func (m model) zenView() string {
+	body := m.overlayView()
+	if body == "" {
+		body = m.editorView()
+	}
+	page := lipgloss.Place(m.width, m.height-1, lipgloss.Center, lipgloss.Top, body)
+
+	switch {
+	case m.mode == renameMode:
+		// The title bar the title is normally edited in is hidden.
+		return page + "\n" + m.titleInput.View()
+	case m.hasStatus():
+		return page + "\n" + m.statusBarView("")
+	}
+	return page
+}
+
+// editorView renders the editor pane.
+func (m model) editorView() string {
+	if !m.wrap {
+		return m.noWrapEditorView(m.editorWidth())
+	}
+	if m.ruler <= 0 && len(m.folds) == 0 {
+		return m.input.View()
+	}
+
+	// Folds and the ruler are drawn between the textarea's top and bottom
+	// borders.
+	border := blurredBorderStyle
+	if m.input.Focused() {
+		border = focusedBorderStyle
+	}
+	lines := strings.Split(m.input.View(), "\n")
+	top, bottom := border.GetBorderTopWidth(), border.GetBorderBottomSize()
+	if len(lines) <= top+bottom {
+		return strings.Join(lines, "\n")
+	}
+	left, right := border.GetBorderLeftSize(), border.GetBorderRightSize()
+	body := m.foldView(lines[top:len(lines)-bottom], left, right)
+	m.rulerView(body, left+lineNumberWidth, right, 0)
+
+	view := append([]string{}, lines[:top]...)
+	view = append(view, body...)
+	return strings.Join(append(view, lines[len(lines)-bottom:]...), "\n")
+}
+
+// statusBarView renders the bottom line of the screen: prompts and status
+// messages when there are any and help otherwise, with the cursor position
+// on the right.
+func (m model) statusBarView(help string) string {
+	left := strings.Builder{}
+
+	if m.mode == confirmQuitMode {
+		left.WriteString("You have unsaved changes. Quit anyway? (y/n)")
+	} else if m.mode == savePathMode {
+		left.WriteString(m.pathInput.View())
+	} else if m.mode == searchMode || m.mode == matchMode {
+		left.WriteString(m.searchView())
+	} else if m.saving {
+		left.WriteString(m.spinner.View() + statusStyle.Render(" Saving…"))
+	} else if m.exporting {
+		left.WriteString(m.spinner.View() + statusStyle.Render(" Exporting PDF…"))
+	} else if m.externalChange {
+		left.WriteString(statusErrorStyle.Render("File changed on disk.") + "  " +
+			statusStyle.Render("alt+r reload • alt+k keep my version"))
+	} else {
+		if m.vim {
+			left.WriteString(m.vimView() + "  ")
+		}
+		if m.status != "" {
+			style := statusStyle
+			if m.statusErr {
+				style = statusErrorStyle
+			}
+			left.WriteString(style.Render(m.status))
+		} else {
+			left.WriteString(help)
+		}
+	}
+
+	position := countStyle.UnsetPadding().Render(fmt.Sprintf("Ln %d, Col %d",
+		m.input.Line()+1, cursorColumn(m.input)+1))
+	if (m.previewVisible || m.readOnly) && !m.zen {
+		position = countStyle.UnsetPadding().Render("Preview "+m.previewScrollPosition()) + "  " + position
+	}
+	if n := len(m.lintIssues); n > 0 {
+		problems := "1 problem"
+		if n > 1 {
+			problems = fmt.Sprintf("%d problems", n)
+		}
+		position = statusErrorStyle.Render(problems) + "  " + position
+	}
+	if diff := m.diffView(); diff != "" {
+		position = diff + "  " + position
+	}
+	if m.goal > 0 {
+		position = goalView(m.stats.words, m.goal) + "  " + position
+	}
+
+	gap := m.width - lipgloss.Width(left.String()) - lipgloss.Width(position)
+	if gap < 1 {
+		gap = 1
+	}
+	return left.String() + strings.Repeat(" ", gap) + position
 }
 
-func saveFile(m model) {
+// helpView lists every key binding, grouped into columns by what they
+// act on.
+func (m model) helpView() string {
+	h := m.help
+	// Leave room for the menu's border and padding.
+	h.Width = m.width - 4
+	groups := [][]key.Binding{
+		{
+			m.keymap.save,
+			m.keymap.saveAndQuit,
+			m.keymap.exportHTML,
+			m.keymap.exportText,
+			m.keymap.exportPDF,
+			m.keymap.openBrowser,
+			m.keymap.copyMarkdown,
+			m.keymap.copyHTML,
+			m.keymap.rename,
+			m.keymap.metadata,
+			m.keymap.nextBuffer,
+			m.keymap.prevBuffer,
+			m.keymap.toggleScratch,
+			m.keymap.quit,
+		},
+		{
+			m.keymap.undo,
+			m.keymap.redo,
+			m.keymap.insertComponent,
+			m.keymap.bold,
+			m.keymap.italic,
+			m.keymap.code,
+			m.keymap.pasteLink,
+			m.keymap.toggleTask,
+			m.keymap.formatTable,
+			m.keymap.promoteHeading,
+			m.keymap.demoteHeading,
+			m.keymap.insertDate,
+			m.keymap.insertDateTime,
+			m.keymap.search,
+		},
+		{
+			m.keymap.toc,
+			m.keymap.outline,
+			m.keymap.toggleFold,
+			m.keymap.lint,
+			m.keymap.checkLinks,
+			m.keymap.palette,
+			m.keymap.togglePreview,
+			m.keymap.toggleLayout,
+			m.keymap.toggleWrap,
+			m.keymap.zen,
+		},
+		{
+			m.keymap.next,
+			m.keymap.shrinkEditor,
+			m.keymap.growEditor,
+			m.keymap.pauseTimer,
+			m.keymap.showHelp,
+			m.keymap.toggleHelpBar,
+		},
+	}
+	return menuStyle.Render("Keys\n\n" + h.FullHelpView(groups) + "\n\n" + m.keymap.showHelp.Help().Key + " or esc close")
+}
+
+// overlayView renders the menu or panel drawn over the editor in the current
+// mode, if there is one.
+func (m model) overlayView() string {
+	switch m.mode {
+	case insertMenuMode, componentPromptMode:
+		return m.insertMenuView()
+	case imagePickMode:
+		return m.imagePickerView()
+	case recentMode:
+		return m.recentView()
+	case tocMode:
+		return m.tocView()
+	case lintMode:
+		return m.lintView()
+	case linkCheckMode:
+		return m.linkCheckView()
+	case helpMode:
+		return m.helpView()
+	case paletteMode:
+		return m.paletteView()
+	case outlineMode:
+		return m.outlineView()
+	}
+	return ""
+}
+
+// newFileContent renders the template a new file at path starts out with,
+// or returns nothing without one. The title is taken from the file's name.
+func newFileContent(t *template.Template, path, dateFormat string) string {
+	if t == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	err := t.Execute(&b, struct{ Title, Date string }{
+		Title: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		Date:  time.Now().Format(dateFormat),
+	})
+	if err != nil {
+		return ""
+	}
+	return b.String()
+}
+
+// fileContent is the buffer as it's written to disk: front matter, line
+// endings and byte order mark included.
+func fileContent(m model) (string, error) {
 	b := strings.Builder{}
 
 	// Front matter
-	homePath, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("Could not find user")
+	if m.frontMatter != frontMatterNone {
+		homePath, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("finding the user for front matter: %w", err)
+		}
+
+		split := strings.Split(homePath, "/")
+		userName := split[len(split)-1]
+
+		// Keys loaded from the file keep their place, with ours merged in.
+		frontMatterData := m.metadata.Clone()
+		frontMatterData.Set("user", userName)
+		frontMatterData.Set("time", m.elapsed().String())
+		if m.title != defaultTitle {
+			frontMatterData.Set("title", m.title)
+		}
+
+		frontMatter, err := formatFrontMatter(m.frontMatter, frontMatterData)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(frontMatter)
 	}
 
-	split := strings.Split(homePath, "/")
-	userName := split[len(split)-1]
+	// Markdown content
+	body := m.input.Value()
+	if m.trim {
+		body = trimTrailingWhitespace(body, m.keepHardBreaks)
+	}
+	if m.expandEmoji {
+		body = expandEmoji(body)
+	}
+	b.WriteString(body)
 
-	frontMatterData := map[string]string{
-		"user": userName,
-		"time": m.stopwatch.Elapsed().String(),
+	content := withLineEnding(b.String(), m.lineEnding)
+	if m.bom {
+		content = bom + content
 	}
+	return content, nil
+}
 
-	frontMatterTemplate, err := template.New("").Parse(`---
-{{ range $k, $v := . }}{{$k}} = "{{$v}}"{{ end }}
----
-`)
-	if err != nil {
-		log.Fatalf("Failed to generate front matter | %v", err)
+// trimTrailingWhitespace strips trailing whitespace from every line and ends
+// the text with exactly one newline. With keepHardBreaks, lines ending in two
+// or more spaces keep two of them, since markdown reads that as a line break.
+func trimTrailingWhitespace(s string, keepHardBreaks bool) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimRight(line, " \t")
+		if keepHardBreaks && trimmed != "" && strings.HasSuffix(line, "  ") {
+			trimmed += "  "
+		}
+		lines[i] = trimmed
 	}
 
-	var bytesBuffer bytes.Buffer
-	if err := frontMatterTemplate.Execute(&bytesBuffer, frontMatterData); err != nil {
-		log.Fatalf("Failed to render template")
+	s = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+	if s == "" {
+		return ""
 	}
+	return s + "\n"
+}
 
-	b.WriteString(bytesBuffer.String())
+// checkFilePath makes sure path can be saved to before the editor starts,
+// rather than finding out on the first save. Missing parent directories are
+// fine since saving creates them.
+func checkFilePath(path string) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
 
-	// Markdown content
+	// The closest ancestor that does exist has to be a directory for the
+	// rest of the path to be created under it.
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) || dir == filepath.Dir(dir) {
+			return err
+		}
+	}
+}
+
+// parseFileMode parses an octal permission string such as 0600. Modes that
+// would stop us from reading back or re-saving the file are rejected.
+func parseFileMode(s string) (os.FileMode, error) {
+	n, err := strconv.ParseInt(s, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid mode %q: must be an octal number like 0644", s)
+	}
+	if n < 0 || n > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: must be between 0000 and 0777", s)
+	}
+	if n&0600 != 0600 {
+		return 0, fmt.Errorf("invalid mode %q: owner must be able to read and write the file", s)
+	}
+	return os.FileMode(n), nil
+}
 
-	b.WriteString(m.input.Value())
+// stringsFlag is a flag that can be given more than once, collecting every
+// value.
+type stringsFlag []string
 
-	os.WriteFile(m.filePath, []byte(b.String()), 0666)
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ", ")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
 }
 
 func main() {
+	os.Exit(run())
+}
+
+// run is the body of main. It returns the exit status rather than exiting
+// itself so that its deferred calls, like closing the log file, still run.
+func run() int {
 
-	filePath := flag.String("file-path", "", "path to markdown file")
+	var filePaths stringsFlag
+	flag.Var(&filePaths, "file-path", "path to markdown file, may be given more than once")
+	theme := flag.String("theme", defaultTheme, "preview style, one of: "+strings.Join(themeNames(), ", "))
+	noTimer := flag.Bool("no-timer", false, "hide the writing timer and don't run it")
+	noAutopair := flag.Bool("no-autopair", false, "don't close brackets and quotes as they're typed")
+	accentColor := flag.String("accent-color", defaultAccentColor, "color of the cursor and other highlights, as an ANSI color number or a hex color like #ff87d7")
+	borderColor := flag.String("border-color", defaultBorderColor, "color of the focused pane's border")
+	highlightColor := flag.String("highlight-color", defaultHighlightColor, "background color of the cursor line and the active tab")
+	styleFile := flag.String("style-file", "", "glamour style JSON file to render the preview with, in place of -theme")
+	codeStyle := flag.String("code-style", "", "Chroma style for code blocks in the preview, e.g. monokai; defaults to the theme's")
+	autosave := flag.Int("autosave", 0, "seconds between automatic saves, 0 to disable")
+	saveOnBlur := flag.Bool("save-on-blur", false, "save changes when the terminal loses focus")
+	smartypants := flag.Bool("smartypants", false, "show curly quotes, em dashes and ellipses in the preview")
+	unwrapPaste := flag.Bool("unwrap-paste", false, "join the hard-wrapped lines of pasted paragraphs")
+	mode := flag.String("mode", defaultMode, "octal permissions for saved files")
+	templatePath := flag.String("template", "", "template new files start out with; may use {{.Title}} and {{.Date}}")
+	output := flag.String("output", "", "path to save to instead of the file that was opened")
+	line := flag.Int("line", 0, "line to start the cursor on")
+	col := flag.Int("col", 1, "column to start the cursor on, with -line")
+	backups := flag.Int("backups", 0, "number of .bak copies of the previous versions to keep when saving, 0 to disable")
+	indent := flag.Int("indent", 0, "number of spaces the tab key inserts, 0 to leave tab alone; shift+tab then switches panes")
+	readOnly := flag.Bool("readonly", false, "open the file for viewing only")
+	vim := flag.Bool("vim", false, "enable vim-style modal editing")
+	trim := flag.Bool("trim", true, "strip trailing whitespace from lines when saving")
+	keepHardBreaks := flag.Bool("keep-hardbreaks", false, "keep two space hard line breaks when trimming")
+	expandEmoji := flag.Bool("expand-emoji", false, "replace emoji shortcodes like :smile: with emoji when saving")
+	wrapWidth := flag.Int("wrap-width", defaultWrapWidth, "column plain text exports are wrapped at, 0 to not wrap")
+	dateFormat := flag.String("date-format", defaultDateFormat, "Go time layout used when inserting dates")
+	dateField := flag.String("date-field", defaultDateField, "front matter key the first save's date is written to, empty for none")
+	frontMatter := flag.String("frontmatter", frontMatterYAML, "front matter format, one of: yaml, toml, json, none")
+	previewWidth := flag.Int("preview-width", defaultPreviewWidth, "widest column the preview wraps at, centered in wider panes; 0 for the pane's width")
+	ruler := flag.Int("ruler", 0, "column to draw a guide after in the editor, highlighting longer lines; 0 for no ruler")
+	goal := flag.Int("goal", 0, "number of words to aim for, shown as a progress bar; 0 for no goal")
+	render := flag.Bool("render", false, "print the rendered markdown and exit instead of opening the editor")
+	assetsDir := flag.String("assets", "", "directory images are inserted from; defaults to the markdown file's")
+	noAltScreen := flag.Bool("no-altscreen", false, "draw inline instead of taking over the screen, leaving the editor in the scrollback on quit")
+	logPath := flag.String("log", "", "file to log errors to, for debugging")
+	showVersion := flag.Bool("version", false, "print the version and exit")
+	listThemes := flag.Bool("list-themes", false, "print the themes -theme accepts and exit")
 	flag.Parse()
+	filePaths = append(filePaths, flag.Args()...)
+
+	if *showVersion {
+		fmt.Println(versionString())
+		return 0
+	}
+
+	if *listThemes {
+		for _, name := range themeNames() {
+			fmt.Println(name)
+		}
+		return 0
+	}
 
-	if *filePath == "" {
-		flag.Usage()
-		os.Exit(1)
+	// Anything logged to the terminal would be drawn over, so logging goes
+	// to a file or nowhere.
+	if *logPath != "" {
+		f, err := tea.LogToFile(*logPath, "markaway")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+	} else {
+		log.SetOutput(io.Discard)
 	}
 
-	if err := tea.NewProgram(newModel(*filePath), tea.WithAltScreen()).Start(); err != nil {
+	themeSet := false
+	flag.Visit(func(f *flag.Flag) {
+		themeSet = themeSet || f.Name == "theme"
+	})
+
+	// Without a path, read the document from stdin if something is being
+	// piped in, and otherwise offer the recently opened files.
+	var content string
+	if info, err := os.Stdin.Stat(); len(filePaths) == 0 && err == nil && info.Mode()&os.ModeCharDevice == 0 {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: reading stdin: %v\n", err)
+			return 1
+		}
+		content = string(b)
+	}
+	for _, path := range filePaths {
+		if err := checkFilePath(path); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	var recent []string
+	recentFile, err := recentPath()
+	if err == nil {
+		if recent, err = loadRecent(recentFile); err != nil {
+			fmt.Fprintf(os.Stderr, "ignoring recent files %s: %v\n", recentFile, err)
+		}
+		if len(filePaths) > 0 && !*render {
+			for i := len(filePaths) - 1; i >= 0; i-- {
+				recent = addRecent(recent, filePaths[i])
+			}
+			if err := saveRecent(recentFile, recent); err != nil {
+				fmt.Fprintf(os.Stderr, "error: saving recent files: %v\n", err)
+			}
+		}
+	}
+
+	if *output != "" {
+		if len(filePaths) > 1 {
+			fmt.Fprintln(os.Stderr, "error: -output can only be used with a single file")
+			return 1
+		}
+		if err := checkFilePath(*output); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	if !validTheme(*theme) {
+		fmt.Fprintf(os.Stderr, "unknown theme %q, using %q instead; see -list-themes for the valid ones\n",
+			*theme, defaultTheme)
+		*theme = defaultTheme
+	}
+
+	var customStyle *ansi.StyleConfig
+	if *styleFile != "" {
+		if customStyle, err = loadStyleFile(*styleFile); err != nil {
+			fmt.Fprintf(os.Stderr, "ignoring style file %s: %v; using the %q theme instead\n", *styleFile, err, *theme)
+		}
+	}
+
+	if *codeStyle != "" && !validCodeStyle(*codeStyle) {
+		fmt.Fprintf(os.Stderr, "unknown code style %q, using the theme's instead\n", *codeStyle)
+		*codeStyle = ""
+	}
+
+	if *previewWidth < 0 {
+		fmt.Fprintln(os.Stderr, "error: -preview-width can't be negative")
+		return 1
+	}
+
+	if *render {
+		// There's no pane for the output to fit, so it's wrapped at the
+		// widest the preview would be.
+		renderWidth := *previewWidth
+		if renderWidth == 0 {
+			renderWidth = defaultPreviewWidth
+		}
+		if err := renderDocuments(os.Stdout, fileStore{}, filePaths, content, *theme, customStyle, *codeStyle, renderWidth); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	if _, ok := frontMatterTemplates[*frontMatter]; !ok && *frontMatter != frontMatterNone {
+		fmt.Fprintf(os.Stderr, "error: unknown front matter format %q\n", *frontMatter)
+		return 1
+	}
+
+	if *indent < 0 {
+		fmt.Fprintln(os.Stderr, "error: -indent can't be negative")
+		return 1
+	}
+
+	if *goal < 0 {
+		fmt.Fprintln(os.Stderr, "error: -goal can't be negative")
+		return 1
+	}
+
+	if *ruler < 0 {
+		fmt.Fprintln(os.Stderr, "error: -ruler can't be negative")
+		return 1
+	}
+
+	for name, c := range map[string]string{
+		"accent-color":    *accentColor,
+		"border-color":    *borderColor,
+		"highlight-color": *highlightColor,
+	} {
+		if !validColor(c) {
+			fmt.Fprintf(os.Stderr, "error: invalid -%s %q, want an ANSI color number from 0 to 255 or a hex color like #ff87d7\n", name, c)
+			return 1
+		}
+	}
+	setColors(*accentColor, *borderColor, *highlightColor)
+
+	if *backups < 0 {
+		fmt.Fprintln(os.Stderr, "error: -backups can't be negative")
+		return 1
+	}
+
+	fileMode, err := parseFileMode(*mode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	var newFileTemplate *template.Template
+	if *templatePath != "" {
+		newFileTemplate, err = template.ParseFiles(*templatePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	// The view is left the way it was last time, but a theme given on the
+	// command line wins over the one it used.
+	var st *state
+	stateFile, err := statePath()
+	if err == nil {
+		if st, err = loadState(stateFile); err != nil {
+			fmt.Fprintf(os.Stderr, "ignoring state file %s: %v\n", stateFile, err)
+		}
+	}
+	if st != nil && !themeSet && validTheme(st.Theme) {
+		*theme = st.Theme
+	}
+
+	var cfg config
+	if path, err := configPath(); err == nil {
+		if cfg, err = loadConfig(path); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	opts := options{
+		filePaths:      filePaths,
+		outputPath:     *output,
+		content:        content,
+		theme:          *theme,
+		codeStyle:      *codeStyle,
+		customStyle:    customStyle,
+		noTimer:        *noTimer,
+		noAutopair:     *noAutopair,
+		saveOnBlur:     *saveOnBlur,
+		unwrapPaste:    *unwrapPaste,
+		smartypants:    *smartypants,
+		autosave:       time.Duration(*autosave) * time.Second,
+		frontMatter:    *frontMatter,
+		vim:            *vim,
+		fileMode:       fileMode,
+		backups:        *backups,
+		readOnly:       *readOnly,
+		indent:         *indent,
+		dateFormat:     *dateFormat,
+		dateField:      *dateField,
+		trim:           *trim,
+		keepHardBreaks: *keepHardBreaks,
+		expandEmoji:    *expandEmoji,
+		wrapWidth:      *wrapWidth,
+		goal:           *goal,
+		previewWidth:   *previewWidth,
+		ruler:          *ruler,
+		assetsDir:      *assetsDir,
+		line:           *line,
+		col:            *col,
+		template:       newFileTemplate,
+		keys:           cfg.keys,
+		state:          st,
+		recent:         recent,
+	}
+
+	// stdin may be the document rather than the keyboard, so read keys from
+	// the terminal directly.
+	programOpts := []tea.ProgramOption{tea.WithInputTTY(), tea.WithMouseCellMotion()}
+	if !*noAltScreen {
+		programOpts = append(programOpts, tea.WithAltScreen())
+	}
+	if *saveOnBlur {
+		fmt.Print(enableFocusReporting)
+	}
+	if *unwrapPaste {
+		fmt.Print(enableBracketedPaste)
+	}
+	final, err := tea.NewProgram(newModel(opts), programOpts...).StartReturningModel()
+	if *saveOnBlur {
+		fmt.Print(disableFocusReporting)
+	}
+	if *unwrapPaste {
+		fmt.Print(disableBracketedPaste)
+	}
+	if m, ok := final.(model); ok {
+		for _, path := range m.tempFiles {
+			os.Remove(path)
+		}
+	}
+	if err != nil {
+		log.Printf("error: running program: %v", err)
 		fmt.Println("Error while running program:", err)
-		os.Exit(1)
+		return 1
 	}
-}
\ No newline at end of file
+
+	// A document's own markaway_theme shouldn't become everyone's.
+	if stateFile != "" {
+		if err := saveState(stateFile, final.(model).state(*theme)); err != nil {
+			fmt.Fprintf(os.Stderr, "error: saving state: %v\n", err)
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// mermaidBox is the box drawn in place of a Mermaid diagram. It's left
+// uncolored so that it reads the same inside the code block it's put in.
+var mermaidBox = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+
+// mermaidPlaceholders replaces the Mermaid diagrams fenced in s, which can't
+// be drawn in the terminal, with a box naming the kind of diagram from its
+// first line.
+func mermaidPlaceholders(s string) string {
+	if !strings.Contains(s, "mermaid") {
+		return s
+	}
+
+	var (
+		out     []string
+		fence   codeFence
+		mermaid bool
+		first   string
+	)
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch fence.next(line) {
+		case openingFence:
+			mermaid = fence.info == "mermaid"
+			first = ""
+			if mermaid {
+				out = append(out, fence.marker)
+				continue
+			}
+		case insideFence:
+			if mermaid {
+				if first == "" {
+					first = trimmed
+				}
+				continue
+			}
+		case closingFence:
+			if mermaid {
+				out = append(out, mermaidBox.Render("Mermaid diagram: "+first), trimmed)
+				continue
+			}
+		}
+		out = append(out, line)
+	}
+
+	// A diagram still being typed runs to the end of the document.
+	if fence.open() && mermaid {
+		out = append(out, mermaidBox.Render("Mermaid diagram: "+first))
+	}
+	return strings.Join(out, "\n")
+}
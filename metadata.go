@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var metadataKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+
+// displayValue shows a front matter value on a single line, turning YAML
+// block sequences into flow sequences.
+func displayValue(v string) string {
+	if !strings.HasPrefix(v, "\n") {
+		return v
+	}
+
+	var items []string
+	for _, line := range strings.Split(strings.TrimSpace(v), "\n") {
+		items = append(items, strings.TrimPrefix(strings.TrimSpace(line), "- "))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+// toggleMetadata expands the front matter panel and moves focus into it, or
+// collapses it again.
+func (m *model) toggleMetadata() tea.Cmd {
+	m.metadataOpen = !m.metadataOpen
+	m.sizeInputs()
+	if !m.metadataOpen {
+		m.mode = editMode
+		return m.input.Focus()
+	}
+
+	m.mode = metadataMode
+	m.metadataCursor = 0
+	m.metadataEditing = false
+	m.input.Blur()
+	return nil
+}
+
+func (m model) updateMetadata(msg tea.KeyMsg) (model, tea.Cmd) {
+	if m.metadataEditing {
+		return m.updateMetadataInput(msg)
+	}
+
+	pairs := m.metadata.Pairs()
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keymap.metadata):
+		cmd := m.toggleMetadata()
+		return m, cmd
+	case msg.String() == "up", msg.String() == "k":
+		if m.metadataCursor > 0 {
+			m.metadataCursor--
+		}
+	case msg.String() == "down", msg.String() == "j":
+		if m.metadataCursor < len(pairs)-1 {
+			m.metadataCursor++
+		}
+	case msg.String() == "enter" && len(pairs) > 0:
+		p := pairs[m.metadataCursor]
+		m.metadataInput = newPromptInput("value")
+		m.metadataInput.SetValue(displayValue(p.Value))
+		m.metadataEditing = true
+		return m, m.metadataInput.Focus()
+	case msg.String() == "a":
+		m.metadataInput = newPromptInput("key: value")
+		m.metadataCursor = len(pairs)
+		m.metadataEditing = true
+		m.sizeInputs()
+		return m, m.metadataInput.Focus()
+	case msg.String() == "d" && len(pairs) > 0:
+		m.metadata = m.metadata.Without(pairs[m.metadataCursor].Key)
+		m.metadataEdited = true
+		if m.metadataCursor > 0 && m.metadataCursor >= len(pairs)-1 {
+			m.metadataCursor--
+		}
+		m.sizeInputs()
+	}
+
+	return m, nil
+}
+
+// updateMetadataInput handles keys while a value is being edited, or a new
+// field typed in as `key: value`.
+func (m model) updateMetadataInput(msg tea.KeyMsg) (model, tea.Cmd) {
+	pairs := m.metadata.Pairs()
+	adding := m.metadataCursor == len(pairs)
+
+	switch msg.String() {
+	case "esc":
+		m.metadataEditing = false
+		if adding && m.metadataCursor > 0 {
+			m.metadataCursor--
+		}
+		m.sizeInputs()
+		return m, nil
+	case "enter":
+		m.metadataEditing = false
+		value := strings.TrimSpace(m.metadataInput.Value())
+
+		var k string
+		if adding {
+			var ok bool
+			if k, value, ok = splitFrontMatterLine(value); !ok {
+				if m.metadataCursor > 0 {
+					m.metadataCursor--
+				}
+				m.sizeInputs()
+				return m, m.setStatus("Fields are added as key: value", true)
+			}
+		} else {
+			k = pairs[m.metadataCursor].Key
+			// Leave values that weren't touched in their original form.
+			if value == displayValue(pairs[m.metadataCursor].Value) {
+				return m, nil
+			}
+		}
+
+		m.metadata.Set(k, value)
+		m.metadataEdited = true
+		if k == "title" && value != "" {
+			m.title = value
+		}
+		m.sizeInputs()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.metadataInput, cmd = m.metadataInput.Update(msg)
+	return m, cmd
+}
+
+// metadataView renders the front matter panel shown above the editor. It
+// collapses to a single line, and is left out entirely when there's no
+// front matter to show.
+func (m model) metadataView() string {
+	pairs := m.metadata.Pairs()
+	if m.readOnly || len(pairs) == 0 && !m.metadataOpen {
+		return ""
+	}
+
+	toggle := m.keymap.metadata.Help().Key
+	if !m.metadataOpen {
+		fields := "1 field"
+		if len(pairs) != 1 {
+			fields = fmt.Sprintf("%d fields", len(pairs))
+		}
+		return countStyle.UnsetPadding().Render(fmt.Sprintf("▸ Front matter · %s (%s)", fields, toggle))
+	}
+
+	b := strings.Builder{}
+	b.WriteString(countStyle.UnsetPadding().Render("▾ Front matter") + "\n")
+	for i, p := range pairs {
+		value := displayValue(p.Value)
+		if m.metadataEditing && i == m.metadataCursor {
+			value = m.metadataInput.View()
+		}
+
+		row := metadataKeyStyle.Render(p.Key+":") + " " + value
+		if i == m.metadataCursor && !m.metadataEditing {
+			row = menuSelectedStyle.Render("> "+p.Key+": ") + value
+		} else {
+			row = "  " + row
+		}
+		b.WriteString(row + "\n")
+	}
+	if m.metadataEditing && m.metadataCursor == len(pairs) {
+		b.WriteString("  " + m.metadataInput.View() + "\n")
+	}
+
+	help := "enter edit • a add • d delete • esc close"
+	if m.metadataEditing {
+		help = "enter confirm • esc cancel"
+	}
+	b.WriteString(countStyle.UnsetPadding().Render(help))
+	return b.String()
+}
+
+// metadataHeight is the number of lines the front matter panel takes up.
+func (m model) metadataHeight() int {
+	view := m.metadataView()
+	if view == "" {
+		return 0
+	}
+	return lipgloss.Height(view)
+}
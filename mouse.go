@@ -0,0 +1,58 @@
+package main
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// mouseScrollLines is how far the editor scrolls per wheel step, matching
+// the viewport's default.
+const mouseScrollLines = 3
+
+// updateMouse scrolls whichever pane the mouse is over with the wheel, and
+// focuses or blurs the editor when a pane is clicked.
+func (m model) updateMouse(msg tea.MouseMsg) (model, tea.Cmd) {
+	if m.mode != editMode {
+		return m, nil
+	}
+
+	// The panes' borders sit outside the body height.
+	top := titleHeight + 1 + m.tabsHeight() + m.metadataHeight()
+	height := m.bodyHeight() + focusedBorderStyle.GetVerticalFrameSize()
+	if m.zen {
+		top, height = 0, m.height-1
+	}
+	if msg.Y < top || msg.Y >= top+height {
+		return m, nil
+	}
+
+	inEditor := !m.readOnly && (m.zen || !m.previewVisible || msg.X < m.editorWidth())
+	if m.layout == verticalLayout && !m.zen && m.previewVisible {
+		inEditor = !m.readOnly && msg.Y < top+m.editorHeight()+focusedBorderStyle.GetVerticalFrameSize()
+	}
+
+	switch msg.Type {
+	case tea.MouseWheelUp, tea.MouseWheelDown:
+		if !inEditor {
+			var cmd tea.Cmd
+			m.viewport, cmd = m.viewport.Update(msg)
+			return m, cmd
+		}
+
+		// The textarea can only scroll by moving its cursor.
+		delta := mouseScrollLines
+		if msg.Type == tea.MouseWheelUp {
+			delta = -delta
+		}
+		moveCursor(&m.input, m.input.Line()+delta, cursorColumn(m.input))
+		cmd := m.scrollToCursor()
+		m.syncPreviewScroll()
+		return m, cmd
+	case tea.MouseLeft:
+		if inEditor {
+			return m, m.input.Focus()
+		}
+		m.input.Blur()
+	}
+
+	return m, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var fuzzyMatchStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Underline(true)
+
+func (m *model) openOutline() tea.Cmd {
+	m.headings = parseHeadings(m.input.Value(), 6)
+	m.menuCursor = 0
+	m.outlineInput = newPromptInput("Type to filter headings")
+	m.mode = outlineMode
+	m.input.Blur()
+	return m.outlineInput.Focus()
+}
+
+// outlineMatches returns the headings matching what's typed in the outline.
+func (m model) outlineMatches() []heading {
+	var matches []heading
+	for _, h := range m.headings {
+		if fuzzyMatch(h.text, m.outlineInput.Value()) {
+			matches = append(matches, h)
+		}
+	}
+	return matches
+}
+
+func (m model) updateOutline(msg tea.KeyMsg) (model, tea.Cmd) {
+	matches := m.outlineMatches()
+
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keymap.outline):
+		m.mode = editMode
+		return m, m.input.Focus()
+	case msg.String() == "up", msg.String() == "ctrl+p":
+		if m.menuCursor > 0 {
+			m.menuCursor--
+		}
+		return m, nil
+	case msg.String() == "down", msg.String() == "ctrl+n":
+		if m.menuCursor < len(matches)-1 {
+			m.menuCursor++
+		}
+		return m, nil
+	case msg.String() == "enter":
+		m.mode = editMode
+		if len(matches) == 0 {
+			return m, m.input.Focus()
+		}
+		return m, m.jumpToHeading(matches[m.menuCursor])
+	}
+
+	var cmd tea.Cmd
+	m.outlineInput, cmd = m.outlineInput.Update(msg)
+	m.menuCursor = 0
+	return m, cmd
+}
+
+// highlightMatch renders s with the characters that fuzzy matched query
+// picked out in style.
+func highlightMatch(s, query string, style lipgloss.Style) string {
+	indexes, _ := fuzzyIndexes(s, query)
+	if len(indexes) == 0 {
+		return s
+	}
+
+	b := strings.Builder{}
+	for i, r := range []rune(s) {
+		if len(indexes) > 0 && indexes[0] == i {
+			b.WriteString(fuzzyMatchStyle.Inherit(style).Render(string(r)))
+			indexes = indexes[1:]
+		} else {
+			b.WriteString(style.Render(string(r)))
+		}
+	}
+	return b.String()
+}
+
+func (m model) outlineView() string {
+	b := strings.Builder{}
+	b.WriteString("> " + m.outlineInput.View() + "\n\n")
+
+	matches := m.outlineMatches()
+	if len(matches) == 0 {
+		b.WriteString("  No matching headings\n")
+	}
+
+	start := 0
+	if m.menuCursor >= maxPaletteEntries {
+		start = m.menuCursor - maxPaletteEntries + 1
+	}
+	for i := start; i < len(matches) && i < start+maxPaletteEntries; i++ {
+		h := matches[i]
+		indent := strings.Repeat("  ", h.level-1)
+		if i == m.menuCursor {
+			b.WriteString(menuSelectedStyle.Render("> "+indent) +
+				highlightMatch(h.text, m.outlineInput.Value(), menuSelectedStyle) + "\n")
+		} else {
+			b.WriteString("  " + indent + highlightMatch(h.text, m.outlineInput.Value(), lipgloss.NewStyle()) + "\n")
+		}
+	}
+
+	b.WriteString("\nenter jump • esc close")
+	return menuStyle.Render(b.String())
+}
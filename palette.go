@@ -0,0 +1,256 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxPaletteEntries is how many commands the palette lists at once.
+const maxPaletteEntries = 10
+
+var paletteKeyStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+
+// command is an action that can be run from the command palette. Its name
+// and key are taken from the binding's help.
+type command struct {
+	binding key.Binding
+	run     func(m *model) tea.Cmd
+}
+
+// commands lists every action the palette can run, in the order they're
+// shown. Actions whose bindings are disabled are left out.
+func (m model) commands() []command {
+	all := []command{
+		{m.keymap.save, (*model).save},
+		{m.keymap.saveAndQuit, (*model).saveAndQuit},
+		{m.keymap.exportHTML, (*model).exportHTMLFile},
+		{m.keymap.exportText, (*model).exportTextFile},
+		{m.keymap.exportPDF, (*model).exportPDFFile},
+		{m.keymap.openBrowser, (*model).openBrowser},
+		{m.keymap.copyMarkdown, (*model).copyMarkdown},
+		{m.keymap.copyHTML, (*model).copyHTML},
+		{m.keymap.insertComponent, func(m *model) tea.Cmd {
+			m.openInsertMenu()
+			return nil
+		}},
+		{m.keymap.togglePreview, (*model).togglePreview},
+		{m.keymap.toggleLayout, (*model).toggleLayout},
+		{m.keymap.bold, func(m *model) tea.Cmd {
+			m.checkpoint()
+			m.insertComponent(boldMarkers)
+			return nil
+		}},
+		{m.keymap.italic, func(m *model) tea.Cmd {
+			m.checkpoint()
+			m.insertComponent(italicMarkers)
+			return nil
+		}},
+		{m.keymap.code, func(m *model) tea.Cmd {
+			m.checkpoint()
+			m.insertComponent(codeMarkers)
+			return nil
+		}},
+		{m.keymap.pasteLink, (*model).pasteLink},
+		{m.keymap.toggleTask, func(m *model) tea.Cmd {
+			m.toggleTask()
+			return nil
+		}},
+		{m.keymap.formatTable, (*model).formatTable},
+		{m.keymap.promoteHeading, func(m *model) tea.Cmd {
+			m.shiftHeading(-1)
+			return nil
+		}},
+		{m.keymap.demoteHeading, func(m *model) tea.Cmd {
+			m.shiftHeading(1)
+			return nil
+		}},
+		{m.keymap.toggleFold, (*model).toggleFold},
+		{m.keymap.insertDate, func(m *model) tea.Cmd {
+			m.insertTime(m.dateFormat)
+			return nil
+		}},
+		{m.keymap.insertDateTime, func(m *model) tea.Cmd {
+			m.insertTime(m.dateFormat + " " + timeOfDayFormat)
+			return nil
+		}},
+		{m.keymap.pauseTimer, func(m *model) tea.Cmd {
+			return m.stopwatch.Toggle()
+		}},
+		{m.keymap.zen, func(m *model) tea.Cmd {
+			m.toggleZen()
+			return nil
+		}},
+		{m.keymap.toggleWrap, func(m *model) tea.Cmd {
+			m.toggleWrap()
+			return nil
+		}},
+		{m.keymap.rename, (*model).startRename},
+		{m.keymap.metadata, (*model).toggleMetadata},
+		{m.keymap.search, (*model).openSearch},
+		{m.keymap.outline, (*model).openOutline},
+		{m.keymap.toc, func(m *model) tea.Cmd {
+			m.openTOC()
+			return nil
+		}},
+		{m.keymap.lint, func(m *model) tea.Cmd {
+			m.openLint()
+			return nil
+		}},
+		{m.keymap.checkLinks, (*model).openLinkCheck},
+		{m.keymap.undo, func(m *model) tea.Cmd {
+			m.undo()
+			return nil
+		}},
+		{m.keymap.redo, func(m *model) tea.Cmd {
+			m.redo()
+			return nil
+		}},
+		{m.keymap.shrinkEditor, func(m *model) tea.Cmd {
+			m.adjustSplit(-splitRatioStep)
+			return nil
+		}},
+		{m.keymap.growEditor, func(m *model) tea.Cmd {
+			m.adjustSplit(splitRatioStep)
+			return nil
+		}},
+		{m.keymap.nextBuffer, func(m *model) tea.Cmd {
+			m.switchBuffer(1)
+			return nil
+		}},
+		{m.keymap.prevBuffer, func(m *model) tea.Cmd {
+			m.switchBuffer(-1)
+			return nil
+		}},
+		{m.keymap.toggleScratch, (*model).toggleScratch},
+		{m.keymap.showHelp, func(m *model) tea.Cmd {
+			m.mode = helpMode
+			return nil
+		}},
+		{m.keymap.toggleHelpBar, (*model).toggleHelpBar},
+		{m.keymap.quit, (*model).quit},
+	}
+
+	enabled := all[:0]
+	for _, c := range all {
+		if c.binding.Enabled() {
+			enabled = append(enabled, c)
+		}
+	}
+	return enabled
+}
+
+// fuzzyMatch reports whether the letters of query appear in s in order,
+// ignoring case.
+func fuzzyMatch(s, query string) bool {
+	_, ok := fuzzyIndexes(s, query)
+	return ok
+}
+
+// fuzzyIndexes returns the positions of the runes in s that the letters of
+// query matched, and whether they all did.
+func fuzzyIndexes(s, query string) ([]int, bool) {
+	rs := []rune(strings.ToLower(s))
+	var indexes []int
+	i := 0
+	for _, q := range strings.ToLower(query) {
+		if unicode.IsSpace(q) {
+			continue
+		}
+		for i < len(rs) && rs[i] != q {
+			i++
+		}
+		if i == len(rs) {
+			return nil, false
+		}
+		indexes = append(indexes, i)
+		i++
+	}
+	return indexes, true
+}
+
+// paletteMatches returns the commands matching what's typed in the palette.
+func (m model) paletteMatches() []command {
+	var matches []command
+	for _, c := range m.commands() {
+		if fuzzyMatch(c.binding.Help().Desc, m.paletteInput.Value()) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func (m *model) openPalette() tea.Cmd {
+	m.mode = paletteMode
+	m.menuCursor = 0
+	m.paletteInput = newPromptInput("Type a command")
+	m.input.Blur()
+	return m.paletteInput.Focus()
+}
+
+func (m model) updatePalette(msg tea.KeyMsg) (model, tea.Cmd) {
+	matches := m.paletteMatches()
+
+	switch msg.String() {
+	case "esc", "ctrl+k":
+		m.mode = editMode
+		return m, m.input.Focus()
+	case "up", "ctrl+p":
+		if m.menuCursor > 0 {
+			m.menuCursor--
+		}
+		return m, nil
+	case "down", "ctrl+n":
+		if m.menuCursor < len(matches)-1 {
+			m.menuCursor++
+		}
+		return m, nil
+	case "enter":
+		m.mode = editMode
+		if len(matches) == 0 {
+			return m, m.input.Focus()
+		}
+		// Commands that open a mode of their own take focus from there.
+		focus := m.input.Focus()
+		cmd := matches[m.menuCursor].run(&m)
+		if m.mode != editMode || !m.input.Focused() {
+			focus = nil
+		}
+		return m, tea.Batch(focus, cmd)
+	}
+
+	var cmd tea.Cmd
+	m.paletteInput, cmd = m.paletteInput.Update(msg)
+	m.menuCursor = 0
+	return m, cmd
+}
+
+func (m model) paletteView() string {
+	b := strings.Builder{}
+	b.WriteString("> " + m.paletteInput.View() + "\n\n")
+
+	matches := m.paletteMatches()
+	if len(matches) == 0 {
+		b.WriteString("  No matching commands\n")
+	}
+
+	// Keep the selection in view when there are more matches than fit.
+	start := 0
+	if m.menuCursor >= maxPaletteEntries {
+		start = m.menuCursor - maxPaletteEntries + 1
+	}
+	for i := start; i < len(matches) && i < start+maxPaletteEntries; i++ {
+		h := matches[i].binding.Help()
+		name := "  " + h.Desc
+		if i == m.menuCursor {
+			name = menuSelectedStyle.Render("> " + h.Desc)
+		}
+		b.WriteString(name + "  " + paletteKeyStyle.Render(h.Key) + "\n")
+	}
+
+	b.WriteString("\nenter run • esc close")
+	return menuStyle.Render(b.String())
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyIndexes(t *testing.T) {
+	tests := []struct {
+		s, query string
+		want     []int
+		ok       bool
+	}{
+		{"Save and quit", "sv", []int{0, 2}, true},
+		{"Save and quit", "SAV", []int{0, 1, 2}, true},
+		{"Save and quit", "save quit", []int{0, 1, 2, 3, 9, 10, 11, 12}, true},
+		{"Save and quit", "xyz", nil, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := fuzzyIndexes(tt.s, tt.query)
+		if ok != tt.ok || ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("fuzzyIndexes(%q, %q) = %v, %v, want %v, %v", tt.s, tt.query, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// With bracketed paste enabled, terminals mark the start and end of pasted
+// text. Like the focus reports, Bubble Tea delivers the markers as alt key
+// presses, the start one with the first of the pasted text stuck to it.
+const (
+	enableBracketedPaste  = "\x1b[?2004h"
+	disableBracketedPaste = "\x1b[?2004l"
+
+	pasteStart = "[200~"
+	pasteEnd   = "[201~"
+)
+
+// updatePaste collects the keys of a bracketed paste, reporting whether msg
+// was part of one. Once it ends the text is inserted with its paragraphs
+// unwrapped.
+func (m model) updatePaste(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	if !m.pasting {
+		if !msg.Alt || !strings.HasPrefix(string(msg.Runes), pasteStart) {
+			return m, nil, false
+		}
+		m.pasting = true
+		m.pasted = pastedRunes(msg.Runes[len(pasteStart):])
+		return m, nil, true
+	}
+
+	switch {
+	case msg.Alt && strings.HasPrefix(string(msg.Runes), pasteEnd):
+		m.pasting = false
+		text := string(m.pasted)
+		m.pasted = nil
+
+		if m.mode != editMode || !m.input.Focused() || m.readOnly {
+			// Prompts get the text as it is, as though it were typed.
+			m, cmd := m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+			return m, cmd, true
+		}
+		m.checkpoint()
+		m.input.InsertString(unwrapText(text))
+		return m, nil, true
+	case msg.Type == tea.KeyRunes, msg.Type == tea.KeySpace:
+		m.pasted = append(m.pasted, pastedRunes(msg.Runes)...)
+	case msg.Type == tea.KeyEnter:
+		m.pasted = append(m.pasted, '\n')
+	case msg.Type == tea.KeyTab:
+		m.pasted = append(m.pasted, '\t')
+	}
+	return m, nil, true
+}
+
+// pastedRunes returns runes read as part of a paste with the carriage
+// returns terminals send for line breaks turned into newlines.
+func pastedRunes(runes []rune) []rune {
+	out := make([]rune, len(runes))
+	for i, r := range runes {
+		if r == '\r' {
+			r = '\n'
+		}
+		out[i] = r
+	}
+	return out
+}
+
+// unwrapText joins the lines of each paragraph in s into one, undoing hard
+// wrapping. Blank lines between paragraphs are kept, as are the lines of
+// fenced code, and headings and list items start lines of their own.
+func unwrapText(s string) string {
+	var (
+		out   []string
+		fence codeFence
+		join  bool
+	)
+	for _, line := range strings.Split(s, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch fence.next(line) {
+		case openingFence:
+			out = append(out, line)
+			join = false
+			continue
+		case insideFence, closingFence:
+			out = append(out, line)
+			continue
+		}
+		if trimmed == "" {
+			out = append(out, "")
+			join = false
+			continue
+		}
+
+		heading := strings.HasPrefix(trimmed, "#")
+		if join && !heading && !listMarker.MatchString(line) {
+			out[len(out)-1] += " " + trimmed
+		} else {
+			out = append(out, strings.TrimRight(line, " \t"))
+		}
+		join = !heading
+	}
+	return strings.Join(out, "\n")
+}
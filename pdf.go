@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pdfConverter is a command line tool that can turn HTML into a PDF.
+type pdfConverter struct {
+	name string
+	args func(html, pdf string) []string
+}
+
+// pdfConverters are the tools tried for PDF exports, in order of preference.
+var pdfConverters = []pdfConverter{
+	{"wkhtmltopdf", func(html, pdf string) []string { return []string{"--quiet", html, pdf} }},
+	{"pandoc", func(html, pdf string) []string { return []string{html, "-o", pdf} }},
+}
+
+// pdfDoneMsg reports the outcome of a PDF export.
+type pdfDoneMsg struct {
+	path string
+	err  error
+}
+
+// findPDFConverter returns the first of pdfConverters that's installed.
+func findPDFConverter() (pdfConverter, bool) {
+	for _, c := range pdfConverters {
+		if _, err := exec.LookPath(c.name); err == nil {
+			return c, true
+		}
+	}
+	return pdfConverter{}, false
+}
+
+// exportPDFFile exports the document as a PDF next to the file, converting
+// its HTML export with whichever of pdfConverters is installed. Converters
+// can be slow, so it's done in the background.
+func (m *model) exportPDFFile() tea.Cmd {
+	if m.filePath == "" {
+		return m.setStatus("save the file before exporting it", true)
+	}
+	if m.exporting {
+		return nil
+	}
+
+	converter, ok := findPDFConverter()
+	if !ok {
+		names := make([]string, len(pdfConverters))
+		for i, c := range pdfConverters {
+			names[i] = c.name
+		}
+		return m.setStatus("Exporting PDFs needs "+strings.Join(names, " or ")+" installed", true)
+	}
+
+	html, err := exportTempHTML(*m)
+	if err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+
+	m.exporting = true
+	path := exportPath(m.filePath, ".pdf")
+	export := func() tea.Msg {
+		defer os.Remove(html)
+
+		var stderr bytes.Buffer
+		cmd := exec.Command(converter.name, converter.args(html, path)...)
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			if msg, _, _ := strings.Cut(strings.TrimSpace(stderr.String()), "\n"); msg != "" {
+				err = errors.New(msg)
+			}
+			return pdfDoneMsg{path, fmt.Errorf("%s failed: %w", converter.name, err)}
+		}
+		return pdfDoneMsg{path, nil}
+	}
+	return tea.Batch(export, m.spinner.Tick)
+}
+
+// finishPDFExport reports how a PDF export went.
+func (m *model) finishPDFExport(msg pdfDoneMsg) tea.Cmd {
+	m.exporting = false
+	if msg.err != nil {
+		return m.setStatus(msg.err.Error(), true)
+	}
+	return m.setStatus("Exported PDF to "+msg.path, false)
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/chroma/styles"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/glamour"
+	"github.com/charmbracelet/glamour/ansi"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	previewCursorBar      = lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Render("▌")
+	previewOverflowMarker = lipgloss.NewStyle().Foreground(lipgloss.Color("241")).Render("›")
+)
+
+// previewScrollColumns is how far left and right scroll the preview.
+const previewScrollColumns = 8
+
+// defaultPreviewWidth is the widest the preview's text is wrapped to. It's
+// glamour's own default, and a comfortable line length to read.
+const defaultPreviewWidth = 80
+
+// previewDelay is how long typing has to pause before the preview is
+// rendered again. Rendering a large document on every keystroke makes
+// typing lag.
+const previewDelay = 150 * time.Millisecond
+
+type previewRenderMsg struct {
+	id int
+}
+
+// updatePreview schedules the preview to be re-rendered once the editor
+// contents stop changing, showing the last render in the meantime. The
+// first render happens straight away.
+func (m *model) updatePreview() tea.Cmd {
+	value := m.input.Value()
+	if m.previewLines == 0 || m.previewWrapWidth() != m.previewWrap {
+		m.renderPreview()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	if value != m.previewSource && value != m.previewPending {
+		m.previewPending = value
+		m.previewRenderID++
+		id := m.previewRenderID
+		cmd = tea.Tick(previewDelay, func(time.Time) tea.Msg {
+			return previewRenderMsg{id}
+		})
+	}
+
+	m.highlightCursorBlock()
+	return cmd
+}
+
+// renderPreview re-renders the markdown preview into the viewport whenever
+// the editor contents have changed since the last render.
+func (m *model) renderPreview() {
+	value := m.input.Value()
+	wrap := m.previewWrapWidth()
+	if value != m.previewSource || m.previewLines == 0 || wrap != m.previewWrap {
+		source := value
+		if m.smartypants {
+			source = smarten(source)
+		}
+		rendered, _ := renderMarkdown(source, m.theme, m.customStyle, m.codeStyle, wrap)
+		m.previewSource = value
+		m.previewWrap = wrap
+		m.previewContent = rendered
+		m.previewLines = strings.Count(rendered, "\n") + 1
+		if c := m.blockCache; c == nil || c.theme != m.theme || c.custom != m.customStyle || c.codeStyle != m.codeStyle || c.width != wrap || c.smartypants != m.smartypants {
+			m.blockCache = &blockCache{theme: m.theme, custom: m.customStyle, codeStyle: m.codeStyle, width: wrap, smartypants: m.smartypants}
+		}
+		m.previewBlocks = m.blockCache.blocks(strings.Split(value, "\n"))
+		m.previewHighlighted = false
+		// Linting is as slow on large documents, so it waits for typing to
+		// pause too.
+		m.lintIssues = lint(value)
+	}
+
+	m.highlightCursorBlock()
+}
+
+// previewWrapWidth is the column the preview is wrapped at: the width of
+// its pane, but no wider than previewWidth.
+func (m model) previewWrapWidth() int {
+	// The gutter and the overflow marker take a column each.
+	width := m.viewport.Width - 2
+	if width < minEditorWidth {
+		return defaultPreviewWidth
+	}
+	if m.previewWidth > 0 && width > m.previewWidth {
+		return m.previewWidth
+	}
+	return width
+}
+
+// renderMarkdown renders in for the terminal with the glamour style theme,
+// wrapped at width and highlighting fenced code with the Chroma style
+// codeStyle. An empty codeStyle keeps the theme's own code colors. Emoji
+// shortcodes are shown as emoji, and Mermaid diagrams as a placeholder. A
+// custom style, loaded with -style-file, is used in place of the theme.
+func renderMarkdown(in, theme string, custom *ansi.StyleConfig, codeStyle string, width int) (string, error) {
+	r, err := newRenderer(theme, custom, codeStyle, width)
+	if err != nil {
+		return "", err
+	}
+	return r.Render(mermaidPlaceholders(expandEmoji(in)))
+}
+
+// newRenderer returns the glamour renderer renderMarkdown renders with.
+func newRenderer(theme string, custom *ansi.StyleConfig, codeStyle string, width int) (*glamour.TermRenderer, error) {
+	style := *glamour.DefaultStyles[theme]
+	if custom != nil {
+		style = *custom
+	}
+	if codeStyle != "" {
+		style.CodeBlock.Theme = codeStyle
+		// A theme's own Chroma colors take precedence over a named style.
+		style.CodeBlock.Chroma = nil
+	}
+
+	return glamour.NewTermRenderer(glamour.WithStyles(style), glamour.WithWordWrap(width))
+}
+
+// renderDocuments writes each of the documents at paths in s to w rendered
+// as it would be in the preview, wrapped at width, front matter left out.
+// Without any paths it renders content instead.
+func renderDocuments(w io.Writer, s store, paths []string, content, theme string, custom *ansi.StyleConfig, codeStyle string, width int) error {
+	if len(paths) == 0 {
+		return renderDocument(w, content, theme, custom, codeStyle, width)
+	}
+
+	for _, path := range paths {
+		doc, err := loadDocument(s, path)
+		if err != nil {
+			return err
+		}
+		if err := renderDocument(w, doc, theme, custom, codeStyle, width); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func renderDocument(w io.Writer, content, theme string, custom *ansi.StyleConfig, codeStyle string, width int) error {
+	content = strings.TrimPrefix(content, bom)
+	_, body := parseFrontMatter(strings.ReplaceAll(content, crlf, lf))
+	rendered, err := renderMarkdown(body, theme, custom, codeStyle, width)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, rendered)
+	return err
+}
+
+// highlightCursorBlock marks the rendered lines of the markdown block the
+// cursor is in with a bar in the preview's left gutter.
+func (m *model) highlightCursorBlock() {
+	lines := strings.Split(m.previewSource, "\n")
+	start, end, ok := blockAt(lines, m.input.Line())
+	if !ok || m.readOnly {
+		ok = false
+		start, end = -1, -1
+	}
+
+	block := [2]int{start, end}
+	if m.previewHighlighted && block == m.previewBlock {
+		return
+	}
+	m.previewBlock = block
+	m.previewHighlighted = true
+
+	from, to := -1, -1
+	if ok {
+		from, to = m.renderedRange(start, end)
+	}
+
+	rendered := strings.Split(m.previewContent, "\n")
+	for i, line := range rendered {
+		if m.previewSearch != nil {
+			line = highlightSearch(line, m.previewSearch)
+		}
+		if i >= from && i <= to {
+			rendered[i] = previewCursorBar + line
+		} else {
+			rendered[i] = " " + line
+		}
+	}
+	m.previewRows = rendered
+	m.previewRowWidths = make([]int, len(rendered))
+	for i, row := range rendered {
+		// glamour pads every line out to its wrapping width.
+		m.previewRowWidths[i] = lipgloss.Width(strings.TrimRight(ansiEscape.ReplaceAllString(row, ""), " "))
+	}
+	m.viewport.SetContent(strings.Join(rendered, "\n"))
+	m.scrollPreviewX(0)
+}
+
+// previewScrollPosition describes how far down the preview is scrolled:
+// "All" when it fits without scrolling, otherwise "Top", "Bot" or a
+// percentage in between.
+func (m model) previewScrollPosition() string {
+	switch {
+	case len(m.previewRows) <= m.viewport.Height:
+		return "All"
+	case m.viewport.AtTop():
+		return "Top"
+	case m.viewport.AtBottom():
+		return "Bot"
+	}
+	return fmt.Sprintf("%d%%", int(math.Round(m.viewport.ScrollPercent()*100)))
+}
+
+// previewFocused reports whether keys that scroll go to the preview rather
+// than the editor, which they do once focus is moved to the preview with
+// tab or by clicking it.
+func (m model) previewFocused() bool {
+	return m.mode == editMode && (m.readOnly || m.previewVisible && !m.zen && !m.input.Focused())
+}
+
+// switchFocus moves focus between the editor and the preview.
+func (m *model) switchFocus() tea.Cmd {
+	if m.input.Focused() {
+		m.input.Blur()
+		return nil
+	}
+	return m.input.Focus()
+}
+
+// isScrollKey reports whether msg is one of the keys that scroll the
+// preview while it has focus. Other keys go back to the editor.
+func isScrollKey(msg tea.KeyMsg) bool {
+	switch msg.String() {
+	case "up", "down", "pgup", "pgdown":
+		return true
+	}
+	return false
+}
+
+// scrollPreviewX scrolls the preview right by delta columns, or left for a
+// negative delta, no further than its widest line.
+func (m *model) scrollPreviewX(delta int) {
+	widest := 0
+	for _, w := range m.previewRowWidths {
+		if w > widest {
+			widest = w
+		}
+	}
+
+	// The gutter stays put and the last column is kept for the overflow
+	// marker.
+	limit := widest - m.viewport.Width + 1
+	m.previewXOffset += delta
+	if m.previewXOffset > limit {
+		m.previewXOffset = limit
+	}
+	if m.previewXOffset < 0 {
+		m.previewXOffset = 0
+	}
+}
+
+// previewView renders the visible part of the preview, scrolled right by
+// previewXOffset. Lines that carry on past the right edge are marked there.
+func (m model) previewView() string {
+	width, height := m.viewport.Width, m.viewport.Height
+	if width < 3 || height < 1 {
+		return m.viewport.View()
+	}
+
+	top := m.viewport.YOffset
+	if top > len(m.previewRows) {
+		top = len(m.previewRows)
+	}
+	bottom := top + height
+	if bottom > len(m.previewRows) {
+		bottom = len(m.previewRows)
+	}
+
+	// Text wrapped narrower than the pane is centered in it.
+	inner := width - 2
+	margin := ""
+	if m.previewWrap < inner {
+		margin = strings.Repeat(" ", (inner-m.previewWrap)/2)
+		inner -= len(margin)
+	}
+	lines := make([]string, height)
+	for i := range lines {
+		if top+i >= bottom {
+			lines[i] = strings.Repeat(" ", width)
+			continue
+		}
+
+		row := m.previewRows[top+i]
+		marker := " "
+		if m.previewRowWidths[top+i] > 1+m.previewXOffset+inner {
+			marker = previewOverflowMarker
+		}
+		lines[i] = ansiSlice(row, 0, 1) + margin + ansiSlice(row, 1+m.previewXOffset, inner) + "\x1b[0m" + marker
+	}
+	return strings.Join(lines, "\n")
+}
+
+// blockAt finds the block of consecutive non-blank lines containing row,
+// treating a fenced code block as a single block.
+func blockAt(lines []string, row int) (int, int, bool) {
+	for _, b := range sourceBlocks(lines) {
+		if row >= b[0] && row <= b[1] {
+			return b[0], b[1], true
+		}
+	}
+	return 0, 0, false
+}
+
+// sourceBlocks splits lines into the blocks blockAt finds, giving the first
+// and last line of each.
+func sourceBlocks(lines []string) [][2]int {
+	var (
+		blocks [][2]int
+		fence  codeFence
+	)
+	start := -1
+	for i, line := range lines {
+		if fence.next(line) == outsideFence && strings.TrimSpace(line) == "" {
+			if start >= 0 {
+				blocks = append(blocks, [2]int{start, i - 1})
+			}
+			start = -1
+			continue
+		}
+
+		if start < 0 {
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		blocks = append(blocks, [2]int{start, len(lines) - 1})
+	}
+	return blocks
+}
+
+// blockRows records that the block of source lines start to end was
+// rendered onto rows from to to of the preview.
+type blockRows struct {
+	start, end int
+	from, to   int
+}
+
+// blockCache remembers how many rows blocks of markdown render to with a
+// theme, code style and wrapping width, so that only the blocks edited since
+// the last render are rendered again.
+type blockCache struct {
+	theme, codeStyle string
+	custom           *ansi.StyleConfig
+	width            int
+	smartypants      bool
+	rows             map[string]int
+	renderer         *glamour.TermRenderer
+}
+
+// blocks approximates which rows of the rendered document each block of
+// lines ended up on, by rendering the blocks one at a time and counting the
+// rows each produces. glamour starts the document with a blank row and
+// separates blocks with another. Blocks that render to nothing, like link
+// definitions, are left out.
+func (c *blockCache) blocks(lines []string) []blockRows {
+	var (
+		blocks []blockRows
+		rows   = map[string]int{}
+		to     = -1
+	)
+	for _, b := range sourceBlocks(lines) {
+		text := strings.Join(lines[b[0]:b[1]+1], "\n")
+		n, ok := rows[text]
+		if !ok {
+			n = c.rowCount(text)
+			rows[text] = n
+		}
+		if n == 0 {
+			continue
+		}
+		from := 1
+		if to >= 0 {
+			from = to + 2
+		}
+		to = from + n - 1
+		blocks = append(blocks, blockRows{b[0], b[1], from, to})
+	}
+
+	// Blocks that are gone aren't likely to come back.
+	c.rows = rows
+	return blocks
+}
+
+// rowCount is how many rows the block text renders to.
+func (c *blockCache) rowCount(text string) int {
+	if n, ok := c.rows[text]; ok {
+		return n
+	}
+	if c.renderer == nil {
+		r, err := newRenderer(c.theme, c.custom, c.codeStyle, c.width)
+		if err != nil {
+			return 0
+		}
+		c.renderer = r
+	}
+	if c.smartypants {
+		text = smarten(text)
+	}
+	out, _ := c.renderer.Render(mermaidPlaceholders(expandEmoji(text)))
+	return len(trimBlankRows(strings.Split(out, "\n")))
+}
+
+// trimBlankRows drops the blank rows around rendered output. Some blocks
+// are rendered with padding of their own that only separates them from the
+// block before.
+func trimBlankRows(rows []string) []string {
+	blank := func(row string) bool {
+		return strings.TrimSpace(ansiEscape.ReplaceAllString(row, "")) == ""
+	}
+	for len(rows) > 0 && blank(rows[0]) {
+		rows = rows[1:]
+	}
+	for len(rows) > 0 && blank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows
+}
+
+// renderedRange looks up the rows of the preview that source lines start
+// to end, a block, were last rendered onto.
+func (m model) renderedRange(start, end int) (int, int) {
+	i := sort.Search(len(m.previewBlocks), func(i int) bool { return m.previewBlocks[i].start >= start })
+	if i == len(m.previewBlocks) || m.previewBlocks[i].start != start {
+		return -1, -1
+	}
+	return m.previewBlocks[i].from, m.previewBlocks[i].to
+}
+
+// syncPreviewScroll scrolls the preview so that the region corresponding to
+// the editor's cursor line is in view, mapping the cursor's position in the
+// source proportionally onto the rendered output.
+func (m *model) syncPreviewScroll() {
+	lines := m.input.LineCount()
+	if lines <= 1 {
+		m.viewport.SetYOffset(0)
+		return
+	}
+
+	ratio := float64(m.input.Line()) / float64(lines-1)
+	target := int(ratio * float64(m.previewLines-1))
+
+	if target < m.viewport.YOffset || target >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(target - m.viewport.Height/2)
+	}
+}
+
+// themeNames returns the names of the glamour styles usable as a theme.
+func themeNames() []string {
+	var names []string
+	for name := range glamour.DefaultStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadStyleFile reads a glamour style from the JSON file at path, in the
+// same format as glamour's own themes.
+func loadStyleFile(path string) (*ansi.StyleConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var style ansi.StyleConfig
+	if err := json.Unmarshal(b, &style); err != nil {
+		return nil, err
+	}
+	return &style, nil
+}
+
+func validTheme(theme string) bool {
+	for _, name := range themeNames() {
+		if name == theme {
+			return true
+		}
+	}
+	return false
+}
+
+func validCodeStyle(name string) bool {
+	for _, style := range styles.Names() {
+		if style == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxRecent is how many recently opened files are remembered.
+const maxRecent = 10
+
+// recentPath is where the recently opened files are listed, next to the
+// state file.
+func recentPath() (string, error) {
+	path, err := statePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "recent.json"), nil
+}
+
+// loadRecent reads the list of recently opened files at path, most recent
+// first. It returns nothing if there isn't one yet.
+func loadRecent(path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if err := json.Unmarshal(content, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func saveRecent(path string, files []string) error {
+	content, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// addRecent moves path to the front of files, adding it if it isn't there
+// yet and dropping the oldest once there are more than maxRecent.
+func addRecent(files []string, path string) []string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	recent := []string{path}
+	for _, f := range files {
+		if f != path && len(recent) < maxRecent {
+			recent = append(recent, f)
+		}
+	}
+	return recent
+}
+
+// openRecent opens the file at path in place of the empty buffer the start
+// screen was shown over.
+func (m *model) openRecent(path string) tea.Cmd {
+	m.filePath = path
+	if err := m.load(); err != nil {
+		m.filePath = ""
+		return m.setStatus(err.Error(), true)
+	}
+	m.restoreCursor()
+	m.readTimeSpent()
+	m.applyDirectives()
+	m.sizeInputs()
+	m.renderPreview()
+
+	var cmd tea.Cmd
+	m.recentFiles = addRecent(m.recentFiles, path)
+	if file, err := recentPath(); err == nil {
+		if err := saveRecent(file, m.recentFiles); err != nil {
+			cmd = m.setStatus("Could not save recent files: "+err.Error(), true)
+		}
+	}
+
+	m.mode = editMode
+	if m.readOnly {
+		return cmd
+	}
+	return tea.Batch(cmd, m.input.Focus())
+}
+
+// updateRecent handles keys on the start screen, which offers a new file
+// followed by the recently opened ones.
+func (m model) updateRecent(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return m, m.quit()
+	case "up", "k":
+		if m.recentCursor > 0 {
+			m.recentCursor--
+		}
+	case "down", "j":
+		if m.recentCursor < len(m.recentFiles) {
+			m.recentCursor++
+		}
+	case "enter":
+		if m.recentCursor == 0 {
+			m.mode = editMode
+			return m, m.input.Focus()
+		}
+		return m, m.openRecent(m.recentFiles[m.recentCursor-1])
+	}
+
+	return m, nil
+}
+
+func (m model) recentView() string {
+	b := strings.Builder{}
+	b.WriteString("markaway\n\n")
+
+	entry := func(i int, name string) {
+		if i == m.recentCursor {
+			b.WriteString(menuSelectedStyle.Render("> "+name) + "\n")
+		} else {
+			b.WriteString("  " + name + "\n")
+		}
+	}
+	entry(0, "New file")
+	if len(m.recentFiles) > 0 {
+		b.WriteString("\nRecent files\n")
+	}
+	for i, path := range m.recentFiles {
+		entry(i+1, path)
+	}
+
+	b.WriteString("\nenter open • esc quit")
+	return menuStyle.Render(b.String())
+}
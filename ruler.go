@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	rulerStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	overLimitStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+)
+
+// rulerView draws the -ruler guide over the rendered editor lines, whose
+// text starts left cells in and ends right cells before their end. offset is
+// how far the editor is scrolled right. Characters past the ruler are
+// highlighted, and the guide itself is drawn wherever its column is blank.
+func (m model) rulerView(lines []string, left, right, offset int) {
+	if m.ruler <= 0 {
+		return
+	}
+	for i, line := range lines {
+		lines[i] = ruleLine(line, left, m.ruler-offset, lipgloss.Width(line)-right)
+	}
+}
+
+// ruleLine draws the ruler into a single line at cell left+col, highlighting
+// what's between there and end. col may be negative when the ruler is
+// scrolled out of view to the left. Escape sequences are kept so the cursor
+// and cursor line styling carry through, and the guide is left out of
+// styled cells so it doesn't cover the cursor.
+func ruleLine(line string, left, col, end int) string {
+	if left+col >= end {
+		return line
+	}
+	open, _, _ := strings.Cut(overLimitStyle.Render("x"), "x")
+
+	var (
+		b      strings.Builder
+		esc    strings.Builder
+		pos    int
+		styled bool
+		inEsc  bool
+		marked bool
+	)
+	for _, r := range line {
+		// Whatever follows the highlighted stretch, like the border, keeps
+		// its own color.
+		if marked && pos >= end {
+			b.WriteString("\x1b[0m")
+			marked = false
+		}
+		if r == '\x1b' {
+			inEsc = true
+			esc.Reset()
+		}
+		if inEsc {
+			b.WriteRune(r)
+			esc.WriteRune(r)
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEsc = false
+				seq := esc.String()
+				styled = seq != "\x1b[0m" && seq != "\x1b[m"
+				if !styled && pos > left+col && pos < end {
+					b.WriteString(open)
+				}
+			}
+			continue
+		}
+
+		switch {
+		case pos == left+col && r == ' ' && !styled:
+			b.WriteString(rulerStyle.Render("│"))
+		case pos >= left && pos >= left+col && pos < end && r != ' ':
+			b.WriteString(open + string(r))
+			marked = open != ""
+		default:
+			b.WriteRune(r)
+		}
+		pos += lipgloss.Width(string(r))
+	}
+	if marked {
+		b.WriteString("\x1b[0m")
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// saveReason is what started a save, which decides how it's reported.
+type saveReason int
+
+const (
+	manualSave saveReason = iota
+	autoSave
+	blurSave
+)
+
+// saveDoneMsg reports the outcome of a save started with startSave. content
+// is the buffer as it was written.
+type saveDoneMsg struct {
+	content string
+	path    string
+	reason  saveReason
+	err     error
+}
+
+func newSpinner() spinner.Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = statusStyle
+	return s
+}
+
+// startSave writes the buffer to disk in the background, so the interface
+// keeps drawing while backups are rotated and large files are written. What's
+// written is worked out up front, since the model's textarea shares its lines
+// with the copy the command would otherwise hold. Keys are ignored until it's
+// done.
+func (m *model) startSave(reason saveReason) tea.Cmd {
+	m.saving = true
+	m.stampDate()
+	done := saveDoneMsg{
+		content: m.input.Value(),
+		path:    m.savePath(),
+		reason:  reason,
+	}
+	content, err := fileContent(*m)
+	store := m.store
+	save := func() tea.Msg {
+		done.err = err
+		if err == nil {
+			done.err = store.Save(done.path, strings.NewReader(content))
+		}
+		return done
+	}
+	return tea.Batch(save, m.spinner.Tick)
+}
+
+// saveAndQuit saves the buffer and quits once it's saved. If the save fails
+// the editor stays open, showing why.
+func (m *model) saveAndQuit() tea.Cmd {
+	m.quitAfterSave = true
+	return m.save()
+}
+
+// stampDate dates the document with the current time under dateField, the
+// first time it's saved. The date is kept as it is from then on.
+func (m *model) stampDate() {
+	if m.dateField == "" || m.frontMatter == frontMatterNone {
+		return
+	}
+	if _, ok := m.metadata.Get(m.dateField); !ok {
+		m.metadata.Set(m.dateField, time.Now().Format(time.RFC3339))
+	}
+}
+
+// finishSave records a completed save and reports how it went.
+func (m *model) finishSave(msg saveDoneMsg) tea.Cmd {
+	m.saving = false
+	if msg.err != nil {
+		m.quitAfterSave = false
+		return m.setStatus(msg.err.Error(), true)
+	}
+
+	m.savedContent = msg.content
+	m.metadataEdited = false
+	m.dirty = m.isDirty()
+	m.recordModTime()
+	m.externalChange = false
+
+	if m.quitAfterSave {
+		m.quitAfterSave = false
+		return m.quit()
+	}
+	switch msg.reason {
+	case autoSave:
+		m.autosavedAt = time.Now()
+		return nil
+	case blurSave:
+		return m.setStatus("Saved on blur", false)
+	}
+	return m.setStatus("Saved to "+msg.path, false)
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var searchHighlightStyle = lipgloss.NewStyle().Background(lipgloss.Color("220")).Foreground(lipgloss.Color("0"))
+
+// match is the position of a search hit in the document: the rune column
+// it starts at, and the byte offsets of its start and end within the line.
+type match struct {
+	row, col   int
+	start, end int
+}
+
+// searchPattern compiles query into the pattern searched for. Unless regex
+// is set the query is matched literally.
+func searchPattern(query string, caseSensitive, regex bool) (*regexp.Regexp, error) {
+	return regexp.Compile(patternSource(query, caseSensitive, regex))
+}
+
+// patternSource is the regular expression searchPattern compiles.
+func patternSource(query string, caseSensitive, regex bool) string {
+	if !regex {
+		query = regexp.QuoteMeta(query)
+	}
+	if !caseSensitive {
+		query = "(?i)" + query
+	}
+	return query
+}
+
+// findMatches returns every non-empty match of re in s, in document order.
+// Matches don't span lines.
+func findMatches(s string, re *regexp.Regexp) []match {
+	var matches []match
+	for row, line := range strings.Split(s, "\n") {
+		for _, loc := range re.FindAllStringIndex(line, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			col := utf8.RuneCountInString(line[:loc[0]])
+			matches = append(matches, match{row: row, col: col, start: loc[0], end: loc[1]})
+		}
+	}
+	return matches
+}
+
+// pattern is the pattern for the current search.
+func (m model) pattern() (*regexp.Regexp, error) {
+	return searchPattern(m.searchQuery, m.searchCaseSensitive, m.searchRegex)
+}
+
+// replaceIn replaces the matches of re in s with the replacement, in which
+// $1 and the like expand to submatches when searching by regular
+// expression.
+func (m model) replaceIn(re *regexp.Regexp, s string) string {
+	if m.searchRegex {
+		return re.ReplaceAllString(s, m.replacement)
+	}
+	return re.ReplaceAllLiteralString(s, m.replacement)
+}
+
+func (m *model) openSearch() tea.Cmd {
+	m.searchInput = textinput.New()
+	m.searchInput.Prompt = "/"
+	m.searchInput.Placeholder = "search"
+	m.searchInput.SetValue(m.searchQuery)
+	m.searchInput.CursorEnd()
+	m.replaceInput = textinput.New()
+	m.replaceInput.Prompt = "→ "
+	m.replaceInput.Placeholder = "replace"
+	m.replaceInput.SetValue(m.replacement)
+	m.replaceInput.CursorEnd()
+	m.mode = searchMode
+	m.input.Blur()
+	return m.searchInput.Focus()
+}
+
+func (m model) updateSearch(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = editMode
+		return m, m.input.Focus()
+	case "alt+c":
+		m.searchCaseSensitive = !m.searchCaseSensitive
+		return m, nil
+	case "alt+r":
+		m.searchRegex = !m.searchRegex
+		return m, nil
+	case "tab", "shift+tab":
+		if m.searchInput.Focused() {
+			m.searchInput.Blur()
+			return m, m.replaceInput.Focus()
+		}
+		m.replaceInput.Blur()
+		return m, m.searchInput.Focus()
+	case "alt+a":
+		m.searchQuery = m.searchInput.Value()
+		m.replacement = m.replaceInput.Value()
+		m.mode = editMode
+		return m, tea.Batch(m.input.Focus(), m.replaceAll())
+	case "enter":
+		m.searchQuery = m.searchInput.Value()
+		m.replacement = m.replaceInput.Value()
+
+		// Replacing with nothing has to be asked for in the replace field,
+		// so r and R don't delete matches by surprise.
+		m.replacing = m.replacement != "" || m.replaceInput.Focused()
+		re, err := m.pattern()
+		if err != nil {
+			return m, m.setStatus("Bad pattern: "+err.Error(), true)
+		}
+		m.matches = nil
+		if m.searchQuery != "" {
+			m.matches = findMatches(m.input.Value(), re)
+		}
+		if len(m.matches) == 0 {
+			m.mode = editMode
+			return m, tea.Batch(
+				m.input.Focus(),
+				m.setStatus(fmt.Sprintf("No matches for %q", m.searchQuery), true),
+			)
+		}
+
+		// Start from the first match after the cursor, wrapping around.
+		m.matchIndex = m.matchAfter(m.input.Line(), cursorColumn(m.input)+1)
+
+		m.mode = matchMode
+		return m, m.jumpToMatch()
+	}
+
+	var cmd tea.Cmd
+	if m.replaceInput.Focused() {
+		m.replaceInput, cmd = m.replaceInput.Update(msg)
+	} else {
+		m.searchInput, cmd = m.searchInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// matchAfter returns the index of the first match at or after row and col,
+// wrapping around to the first one.
+func (m model) matchAfter(row, col int) int {
+	for i, mt := range m.matches {
+		if mt.row > row || (mt.row == row && mt.col >= col) {
+			return i
+		}
+	}
+	return 0
+}
+
+// updateMatches steps through search results with n and N, and replaces
+// them with r, or all of them with R. If no replacement was given r and R
+// open the replace field for one first. Any other key leaves search and is
+// handled as a normal editing key.
+func (m model) updateMatches(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	if !m.replacing && (msg.String() == "r" || msg.String() == "R") {
+		m.openSearch()
+		m.searchInput.Blur()
+		return m, m.replaceInput.Focus(), true
+	}
+
+	switch msg.String() {
+	case "n":
+		m.matchIndex = (m.matchIndex + 1) % len(m.matches)
+		return m, m.jumpToMatch(), true
+	case "N":
+		m.matchIndex = (m.matchIndex - 1 + len(m.matches)) % len(m.matches)
+		return m, m.jumpToMatch(), true
+	case "r":
+		return m, m.replaceMatch(), true
+	case "R":
+		m.mode = editMode
+		return m, m.replaceAll(), true
+	case "esc":
+		m.mode = editMode
+		return m, nil, true
+	}
+
+	m.mode = editMode
+	return m, nil, false
+}
+
+// clearMatches forgets the search results, leaving matchMode, for when the
+// text they were found in is replaced.
+func (m *model) clearMatches() {
+	m.matches = nil
+	if m.mode == matchMode {
+		m.mode = editMode
+	}
+}
+
+// currentMatch returns the match being stepped through, unless the document
+// has changed so that it's no longer there.
+func (m model) currentMatch() (match, bool) {
+	if m.matchIndex < 0 || m.matchIndex >= len(m.matches) {
+		return match{}, false
+	}
+	mt := m.matches[m.matchIndex]
+	lines := strings.Split(m.input.Value(), "\n")
+	if mt.row >= len(lines) || mt.end > len(lines[mt.row]) {
+		return match{}, false
+	}
+	return mt, true
+}
+
+// replaceMatch replaces the current match and moves on to the next one,
+// leaving search once there are none left.
+func (m *model) replaceMatch() tea.Cmd {
+	re, err := m.pattern()
+	if err != nil {
+		m.mode = editMode
+		return m.setStatus("Bad pattern: "+err.Error(), true)
+	}
+
+	mt, ok := m.currentMatch()
+	if !ok {
+		m.clearMatches()
+		return m.setStatus("The document has changed, search again", true)
+	}
+	lines := strings.Split(m.input.Value(), "\n")
+	line := lines[mt.row]
+	replaced := m.replacement
+	if m.searchRegex {
+		// Submatches are expanded from the match in its line, where anchors
+		// like ^ still hold.
+		for _, loc := range re.FindAllStringSubmatchIndex(line, -1) {
+			if loc[0] == mt.start {
+				replaced = string(re.ExpandString(nil, m.replacement, line, loc))
+				break
+			}
+		}
+	}
+	lines[mt.row] = line[:mt.start] + replaced + line[mt.end:]
+
+	m.checkpoint()
+	m.setValue(strings.Join(lines, "\n"), mt.row, mt.col)
+
+	// Carry on after the replacement, so it isn't matched again.
+	m.matches = findMatches(m.input.Value(), re)
+	if len(m.matches) == 0 {
+		return m.setStatus("Replaced the last match", false)
+	}
+	m.mode = matchMode
+	m.matchIndex = m.matchAfter(mt.row, mt.col+utf8.RuneCountInString(replaced))
+	return m.jumpToMatch()
+}
+
+// replaceAll replaces every match in the document as a single edit, so one
+// undo puts them all back.
+func (m *model) replaceAll() tea.Cmd {
+	re, err := m.pattern()
+	if err != nil {
+		return m.setStatus("Bad pattern: "+err.Error(), true)
+	}
+	if m.searchQuery == "" {
+		return nil
+	}
+
+	n := 0
+	lines := strings.Split(m.input.Value(), "\n")
+	for i, line := range lines {
+		if found := len(findMatches(line, re)); found > 0 {
+			n += found
+			lines[i] = m.replaceIn(re, line)
+		}
+	}
+	m.matches = nil
+	if n == 0 {
+		return m.setStatus(fmt.Sprintf("No matches for %q", m.searchQuery), true)
+	}
+
+	m.checkpoint()
+	m.setValue(strings.Join(lines, "\n"), m.input.Line(), cursorColumn(m.input))
+	if n == 1 {
+		return m.setStatus("Replaced 1 match", false)
+	}
+	return m.setStatus(fmt.Sprintf("Replaced %d matches", n), false)
+}
+
+// syncPreviewSearch highlights the matches for the query being typed in
+// searchMode, or stepped through in matchMode, in the preview, scrolling it
+// to the first one as the query changes. Outside of search, or with an
+// empty query, nothing's highlighted.
+func (m *model) syncPreviewSearch() {
+	var query string
+	switch m.mode {
+	case searchMode:
+		query = m.searchInput.Value()
+	case matchMode:
+		query = m.searchQuery
+	}
+
+	source := ""
+	if query != "" {
+		source = patternSource(query, m.searchCaseSensitive, m.searchRegex)
+	}
+	if source == m.previewPattern {
+		return
+	}
+	m.previewPattern = source
+
+	// A pattern that's only half typed doesn't highlight anything yet.
+	m.previewSearch = nil
+	if source != "" {
+		m.previewSearch, _ = regexp.Compile(source)
+	}
+	m.previewHighlighted = false
+	m.highlightCursorBlock()
+
+	if m.previewSearch == nil || m.mode != searchMode {
+		return
+	}
+	for i, row := range m.previewRows {
+		if m.previewSearch.MatchString(ansiEscape.ReplaceAllString(row, "")) {
+			m.viewport.SetYOffset(i - m.viewport.Height/2)
+			return
+		}
+	}
+}
+
+// highlightSearch highlights the matches of re in s, a line of rendered
+// output. Matching is done on the text without its escape sequences, which
+// are kept as they are around the highlights.
+func highlightSearch(s string, re *regexp.Regexp) string {
+	open, _, _ := strings.Cut(searchHighlightStyle.Render("x"), "x")
+	if open == "" {
+		return s
+	}
+
+	// Find the matches in the plain text, noting where each of its bytes
+	// came from.
+	var (
+		plain strings.Builder
+		raw   []int
+	)
+	for i := 0; i < len(s); {
+		if loc := ansiEscape.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			i += loc[1]
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		for k := 0; k < size; k++ {
+			raw = append(raw, i+k)
+		}
+		plain.WriteString(s[i : i+size])
+		i += size
+	}
+	var locs [][]int
+	for _, loc := range re.FindAllStringIndex(plain.String(), -1) {
+		if loc[0] < loc[1] {
+			locs = append(locs, loc)
+		}
+	}
+	if len(locs) == 0 {
+		return s
+	}
+
+	// The highlight is opened again after every escape sequence inside a
+	// match, since glamour resets its styling as it goes. After a match
+	// the styling it interrupted is put back.
+	var (
+		b       strings.Builder
+		active  string
+		inMatch bool
+		p       int
+	)
+	for i := 0; i < len(s); {
+		if loc := ansiEscape.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+			seq := s[i : i+loc[1]]
+			b.WriteString(seq)
+			if seq == "\x1b[0m" || seq == "\x1b[m" {
+				active = ""
+			} else {
+				active += seq
+			}
+			if inMatch {
+				b.WriteString(open)
+			}
+			i += loc[1]
+			continue
+		}
+
+		if len(locs) > 0 && p == locs[0][0] {
+			b.WriteString(open)
+			inMatch = true
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		b.WriteString(s[i : i+size])
+		i += size
+		p += size
+		if inMatch && p == locs[0][1] {
+			b.WriteString("\x1b[0m" + active)
+			inMatch = false
+			locs = locs[1:]
+		}
+	}
+	return b.String()
+}
+
+func (m *model) jumpToMatch() tea.Cmd {
+	mt, ok := m.currentMatch()
+	if !ok {
+		m.clearMatches()
+		return m.setStatus("The document has changed, search again", true)
+	}
+	moveCursor(&m.input, mt.row, mt.col)
+	cmd := m.scrollToCursor()
+	m.renderPreview()
+	m.syncPreviewScroll()
+	return cmd
+}
+
+// searchView renders the search prompt or match position for the status bar.
+func (m model) searchView() string {
+	options := "case-insensitive"
+	if m.searchCaseSensitive {
+		options = "case-sensitive"
+	}
+	if m.searchRegex {
+		options += ", regex"
+	}
+
+	if m.mode == searchMode {
+		return fmt.Sprintf("%s  %s  %s", m.searchInput.View(), m.replaceInput.View(),
+			statusStyle.Render(options+" • alt+c case • alt+r regex • tab replace with • enter search • alt+a replace all • esc cancel"))
+	}
+
+	keys := "n next • N previous • r replace • esc done"
+	if m.replacing {
+		keys = fmt.Sprintf("n next • N previous • r replace with %q • R replace all • esc done", m.replacement)
+	}
+	return fmt.Sprintf("/%s  %d/%d  %s", m.searchQuery, m.matchIndex+1, len(m.matches), statusStyle.Render(keys))
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindMatches(t *testing.T) {
+	tests := []struct {
+		name          string
+		s, query      string
+		caseSensitive bool
+		regex         bool
+		want          []match
+	}{
+		{
+			name:  "literal",
+			s:     "foo bar foo",
+			query: "foo",
+			want:  []match{{0, 0, 0, 3}, {0, 8, 8, 11}},
+		},
+		{
+			name:  "case-insensitive",
+			s:     "Foo\nfOO",
+			query: "foo",
+			want:  []match{{0, 0, 0, 3}, {1, 0, 0, 3}},
+		},
+		{
+			name:          "case-sensitive",
+			s:             "Foo\nfoo",
+			query:         "foo",
+			caseSensitive: true,
+			want:          []match{{1, 0, 0, 3}},
+		},
+		{
+			name:  "literal metacharacters",
+			s:     "a.b axb",
+			query: "a.b",
+			want:  []match{{0, 0, 0, 3}},
+		},
+		{
+			name:  "regex",
+			s:     "a.b axb",
+			query: "a.b",
+			regex: true,
+			want:  []match{{0, 0, 0, 3}, {0, 4, 4, 7}},
+		},
+		{
+			name:  "columns count runes",
+			s:     "héllo wörld",
+			query: "w",
+			want:  []match{{0, 6, 7, 8}},
+		},
+		{
+			name:  "empty matches skipped",
+			s:     "abc",
+			query: "x*",
+			regex: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			re, err := searchPattern(tt.query, tt.caseSensitive, tt.regex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := findMatches(tt.s, re); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findMatches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplaceIn(t *testing.T) {
+	tests := []struct {
+		name        string
+		s, query    string
+		replacement string
+		regex       bool
+		want        string
+	}{
+		{
+			name:        "literal",
+			s:           "foo bar foo",
+			query:       "foo",
+			replacement: "baz",
+			want:        "baz bar baz",
+		},
+		{
+			name:        "literal keeps dollar signs",
+			s:           "cost",
+			query:       "cost",
+			replacement: "$1",
+			want:        "$1",
+		},
+		{
+			name:        "regex expands submatches",
+			s:           "John Smith",
+			query:       `(\w+) (\w+)`,
+			replacement: "$2, $1",
+			regex:       true,
+			want:        "Smith, John",
+		},
+		{
+			name:        "empty replacement",
+			s:           "a-b-c",
+			query:       "-",
+			replacement: "",
+			want:        "abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := model{searchRegex: tt.regex, replacement: tt.replacement}
+			re, err := searchPattern(tt.query, false, tt.regex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.replaceIn(re, tt.s); got != tt.want {
+				t.Errorf("replaceIn(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
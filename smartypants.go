@@ -0,0 +1,98 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// smartSkip matches the parts of a line that aren't prose even outside code:
+// link destinations, autolinks and HTML tags, and bare URLs.
+var smartSkip = regexp.MustCompile(`\]\([^)]*\)|<[^>]*>|https?://\S+`)
+
+// smarten typesets s the way SmartyPants does: straight quotes become curly
+// ones, -- and --- become em dashes and ... an ellipsis. Fenced code blocks,
+// inline code and lines that are markup made of dashes, such as rules,
+// setext underlines and table delimiters, are left alone.
+func smarten(s string) string {
+	lines := strings.Split(s, "\n")
+	var fence codeFence
+	for i, line := range lines {
+		if fence.next(line) != outsideFence {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.Trim(trimmed, "-*_=|: ") == "" {
+			continue
+		}
+
+		// Code spans are the odd numbered pieces between backticks.
+		spans := strings.Split(line, "`")
+		for j := 0; j < len(spans); j += 2 {
+			spans[j] = smartenText(spans[j])
+		}
+		lines[i] = strings.Join(spans, "`")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// smartenText typesets a piece of prose with no code in it.
+func smartenText(s string) string {
+	skip := smartSkip.FindAllStringIndex(s, -1)
+	var b strings.Builder
+	prev := ' '
+	for i := 0; i < len(s); {
+		if len(skip) > 0 && i == skip[0][0] {
+			b.WriteString(s[i:skip[0][1]])
+			i = skip[0][1]
+			prev, _ = utf8.DecodeLastRuneInString(s[:i])
+			skip = skip[1:]
+			continue
+		}
+
+		rest := s[i:]
+		switch {
+		case strings.HasPrefix(rest, "---"):
+			b.WriteString("—")
+			i += 3
+			prev = '—'
+			continue
+		case strings.HasPrefix(rest, "--"):
+			b.WriteString("—")
+			i += 2
+			prev = '—'
+			continue
+		case strings.HasPrefix(rest, "..."):
+			b.WriteString("…")
+			i += 3
+			prev = '…'
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		switch {
+		case r != '"' && r != '\'' || prev == '\\':
+			// Escaped quotes stay straight.
+			b.WriteRune(r)
+		case r == '"' && opensQuote(prev):
+			b.WriteRune('“')
+		case r == '"':
+			b.WriteRune('”')
+		case opensQuote(prev):
+			b.WriteRune('‘')
+		default:
+			b.WriteRune('’')
+		}
+		i += size
+		prev = r
+	}
+	return b.String()
+}
+
+// opensQuote reports whether a quote after prev opens a quotation rather
+// than closing one or being an apostrophe.
+func opensQuote(prev rune) bool {
+	return unicode.IsSpace(prev) || strings.ContainsRune("([{—–-*_", prev)
+}
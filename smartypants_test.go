@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestSmarten(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{`"Hi," she said -- it's 'fine'...`, "“Hi,” she said — it’s ‘fine’…"},
+		{"a --- b", "a — b"},
+		{"```\n\"x\" -- y\n```", "```\n\"x\" -- y\n```"},
+		{"use `\"code\"` here", "use `\"code\"` here"},
+		{"---", "---"},
+		{"[link](http://a--b)", "[link](http://a--b)"},
+	}
+
+	for _, tt := range tests {
+		if got := smarten(tt.in); got != tt.want {
+			t.Errorf("smarten(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// state is how the view was left at the end of the last session, restored
+// at the start of the next.
+type state struct {
+	PreviewVisible bool    `json:"previewVisible"`
+	SplitRatio     float64 `json:"splitRatio"`
+	Stacked        bool    `json:"stacked"`
+	Zen            bool    `json:"zen"`
+	Theme          string  `json:"theme"`
+
+	// Cursors are where the cursor was left in each file, by absolute path.
+	Cursors map[string]position `json:"cursors,omitempty"`
+}
+
+// position is a cursor position, counting from zero.
+type position struct {
+	Line int `json:"line"`
+	Col  int `json:"col"`
+}
+
+// cursorKey is the key a file's cursor position is kept under.
+func cursorKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
+
+// statePath is where the state file is kept, following the XDG base
+// directory convention on every platform.
+func statePath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "markaway", "state.json"), nil
+}
+
+// loadState reads the state file at path. It returns nil if there isn't one
+// yet.
+func loadState(path string) (*state, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var s state
+	if err := json.Unmarshal(content, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func saveState(path string, s state) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// state captures the view preferences worth keeping for next time, with
+// theme as the theme. A preview that's only hidden because the terminal is
+// narrow still counts as shown.
+// The cursor positions of files open this session are added to the ones
+// carried over from earlier sessions.
+func (m model) state(theme string) state {
+	cursors := make(map[string]position, len(m.cursors)+len(m.buffers))
+	for path, pos := range m.cursors {
+		cursors[path] = pos
+	}
+	for i, b := range m.buffers {
+		// The active buffer's stashed copy is out of date.
+		if i == m.active && !m.scratchActive {
+			b = m.currentBuffer()
+		}
+		if b.filePath != "" {
+			cursors[cursorKey(b.filePath)] = position{b.input.Line(), cursorColumn(b.input)}
+		}
+	}
+
+	return state{
+		PreviewVisible: m.previewVisible || m.previewCollapsed,
+		SplitRatio:     m.splitRatio,
+		Stacked:        m.layout == verticalLayout,
+		Zen:            m.zen,
+		Theme:          theme,
+		Cursors:        cursors,
+	}
+}
+
+// restoreState applies the view preferences saved by an earlier session.
+// Zen mode shows only the editor, so it's left off when read-only.
+func (m *model) restoreState(s state) {
+	m.previewVisible = s.PreviewVisible
+	m.zen = s.Zen && !m.readOnly
+	if s.Stacked {
+		m.layout = verticalLayout
+	}
+	if s.SplitRatio >= minSplitRatio && s.SplitRatio <= maxSplitRatio {
+		m.splitRatio = s.SplitRatio
+	}
+	m.cursors = s.Cursors
+}
+
+// restoreCursor puts the cursor back where it was left in the file the last
+// time it was open.
+func (m *model) restoreCursor() {
+	if pos, ok := m.cursors[cursorKey(m.filePath)]; ok {
+		moveCursor(&m.input, pos.Line, pos.Col)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// wordsPerMinute is the reading speed reading time estimates assume.
+	wordsPerMinute = 200
+
+	// goalBarWidth is the width of the word count goal's progress bar.
+	goalBarWidth = 20
+)
+
+var (
+	goalBarStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("99"))
+	goalEmptyStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("238"))
+	goalReachedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("42"))
+)
+
+// markdownSyntax are characters that carry formatting rather than prose, and
+// so don't make a word on their own.
+const markdownSyntax = "*_`#>~-=+|[]()!:"
+
+// docStats are counts of the buffer that are too slow to work out again
+// for every frame drawn, as of when it was last changed or saved.
+type docStats struct {
+	value, saved   string
+	words, chars   int
+	added, removed int
+}
+
+// updateStats brings the buffer's counts up to date after it's changed or
+// been saved.
+func (m *model) updateStats() {
+	value := m.input.Value()
+	if value == m.stats.value && m.savedContent == m.stats.saved {
+		return
+	}
+	added, removed := diffLines(m.savedContent, value)
+	m.stats = docStats{
+		value:   value,
+		saved:   m.savedContent,
+		words:   countWords(value),
+		chars:   countChars(value),
+		added:   added,
+		removed: removed,
+	}
+}
+
+// countWords counts whitespace separated words in s, ignoring tokens that are
+// made up entirely of markdown syntax such as list bullets or heading marks.
+// Only the body counts, so front matter at the top of s is skipped, which
+// keeps reading time estimates to the prose as well.
+func countWords(s string) int {
+	_, s = parseFrontMatter(s)
+	n := 0
+	for _, field := range strings.Fields(s) {
+		if strings.Trim(field, markdownSyntax) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// countChars counts the characters (not bytes) in s.
+func countChars(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// formatCount formats n with comma thousands separators, e.g. 7,310.
+func formatCount(n int) string {
+	if n < 0 {
+		return "-" + formatCount(-n)
+	}
+
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// readingTime estimates how long words take to read, e.g. "~6 min read".
+func readingTime(words int) string {
+	minutes := int(math.Round(float64(words) / wordsPerMinute))
+	if minutes < 1 {
+		return "<1 min read"
+	}
+	return fmt.Sprintf("~%d min read", minutes)
+}
+
+// goalView draws a progress bar of words against goal, e.g.
+// "█████░░░░░ 1,000/2,000 words", that turns green once it's reached.
+func goalView(words, goal int) string {
+	filled := goalBarWidth
+	style := goalReachedStyle
+	if words < goal {
+		filled = goalBarWidth * words / goal
+		style = goalBarStyle
+	}
+
+	bar := style.Render(strings.Repeat("█", filled)) +
+		goalEmptyStyle.Render(strings.Repeat("░", goalBarWidth-filled))
+	return bar + " " + style.Render(fmt.Sprintf("%s/%s words", formatCount(words), formatCount(goal)))
+}
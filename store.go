@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// store is where documents are loaded from and saved to, exports included,
+// and watched for changes made outside the editor. fileStore keeps them on
+// the local filesystem, but anything that can read and write a document by
+// path will do. Temporary files handed to other programs, like a browser,
+// and markaway's own settings stay on the local filesystem regardless.
+type store interface {
+	// Load opens the document at path for reading. A document that doesn't
+	// exist yet is reported with an error wrapping os.ErrNotExist.
+	Load(path string) (io.ReadCloser, error)
+
+	// Save writes data as the document at path, replacing it if it's
+	// already there.
+	Save(path string, data io.Reader) error
+
+	// ModTime reports when the document at path was last changed.
+	ModTime(path string) (time.Time, error)
+}
+
+// fileStore stores documents as local files written with mode, keeping up
+// to backups of their earlier versions as .bak files.
+type fileStore struct {
+	mode    os.FileMode
+	backups int
+}
+
+func (s fileStore) Load(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+func (s fileStore) ModTime(path string) (time.Time, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+func (s fileStore) Save(path string, data io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	if err := backupFile(path, s.backups, s.mode); err != nil {
+		return fmt.Errorf("backing up %s: %w", path, err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, s.mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadDocument reads the whole of the document at path from s.
+func loadDocument(s store, path string) (string, error) {
+	r, err := s.Load(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	return string(content), err
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tableDelimiter matches the row under a table's header that sets each
+// column's alignment, e.g. "| :--- | :---: | ---: |".
+var tableDelimiter = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+
+// alignment is how a table column's cells are lined up.
+type alignment int
+
+const (
+	alignNone alignment = iota
+	alignLeft
+	alignCenter
+	alignRight
+)
+
+// isTableRow reports whether line could be a row of a pipe table.
+func isTableRow(line string) bool {
+	return strings.Contains(line, "|") && strings.TrimSpace(line) != ""
+}
+
+// splitRow splits a table row into its trimmed cells. Escaped pipes, \|,
+// are part of a cell rather than the end of one.
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	if strings.HasSuffix(line, "|") && !strings.HasSuffix(line, `\|`) {
+		line = line[:len(line)-1]
+	}
+
+	var cells []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cells = append(cells, strings.TrimSpace(line[start:i]))
+			start = i + 1
+		}
+	}
+	return append(cells, strings.TrimSpace(line[start:]))
+}
+
+// cellAt returns the index of the cell of a table row that rune column col
+// falls in.
+func cellAt(line []rune, col int) int {
+	cell := 0
+	for i := 0; i < col && i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '|':
+			cell++
+		}
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(line)), "|") && cell > 0 {
+		cell--
+	}
+	return cell
+}
+
+// columnAlignment reads a column's alignment from its delimiter cell.
+func columnAlignment(cell string) alignment {
+	left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+	switch {
+	case left && right:
+		return alignCenter
+	case left:
+		return alignLeft
+	case right:
+		return alignRight
+	}
+	return alignNone
+}
+
+// padCell pads s with spaces to width, lined up as align says.
+func padCell(s string, width int, align alignment) string {
+	gap := width - lipgloss.Width(s)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", gap) + s
+	case alignCenter:
+		return strings.Repeat(" ", gap/2) + s + strings.Repeat(" ", gap-gap/2)
+	}
+	return s + strings.Repeat(" ", gap)
+}
+
+// delimiterCell draws a column's delimiter cell at width, keeping its
+// alignment markers.
+func delimiterCell(width int, align alignment) string {
+	switch align {
+	case alignLeft:
+		return ":" + strings.Repeat("-", width-1)
+	case alignCenter:
+		return ":" + strings.Repeat("-", width-2) + ":"
+	case alignRight:
+		return strings.Repeat("-", width-1) + ":"
+	}
+	return strings.Repeat("-", width)
+}
+
+// formatRows lines up the cells of a table's rows, the second of which is
+// its delimiter row, padding every column to its widest cell.
+func formatRows(rows []string) []string {
+	cells := make([][]string, len(rows))
+	columns := 0
+	for i, row := range rows {
+		cells[i] = splitRow(row)
+		if len(cells[i]) > columns {
+			columns = len(cells[i])
+		}
+	}
+
+	aligns := make([]alignment, columns)
+	widths := make([]int, columns)
+	for c := range widths {
+		// Wide enough for the delimiter's dashes and colons.
+		widths[c] = 3
+	}
+	for i, row := range cells {
+		for c := range row {
+			if i == 1 {
+				aligns[c] = columnAlignment(row[c])
+				continue
+			}
+			if w := lipgloss.Width(row[c]); w > widths[c] {
+				widths[c] = w
+			}
+		}
+	}
+
+	formatted := make([]string, len(rows))
+	for i, row := range cells {
+		padded := make([]string, columns)
+		for c := range padded {
+			switch {
+			case i == 1:
+				padded[c] = delimiterCell(widths[c], aligns[c])
+			case c < len(row):
+				padded[c] = padCell(row[c], widths[c], aligns[c])
+			default:
+				padded[c] = strings.Repeat(" ", widths[c])
+			}
+		}
+		formatted[i] = "| " + strings.Join(padded, " | ") + " |"
+	}
+	return formatted
+}
+
+// formatTable lines up the columns of the pipe table the cursor is in,
+// keeping the cursor in the same cell.
+func (m *model) formatTable() tea.Cmd {
+	row := m.input.Line()
+	lines := strings.Split(m.input.Value(), "\n")
+	if !isTableRow(lines[row]) {
+		return m.setStatus("The cursor isn't in a table", true)
+	}
+
+	start, end := row, row
+	for start > 0 && isTableRow(lines[start-1]) {
+		start--
+	}
+	for end < len(lines)-1 && isTableRow(lines[end+1]) {
+		end++
+	}
+	if end == start || !tableDelimiter.MatchString(lines[start+1]) {
+		return m.setStatus("The cursor isn't in a table", true)
+	}
+
+	cell := cellAt([]rune(lines[row]), cursorColumn(m.input))
+	copy(lines[start:end+1], formatRows(lines[start:end+1]))
+
+	// Put the cursor at the start of the cell it was in.
+	col := 0
+	formatted := []rune(lines[row])
+	for i, r := range formatted {
+		if r == '|' && (i == 0 || formatted[i-1] != '\\') {
+			if cell < 0 {
+				break
+			}
+			cell--
+			col = i + 2
+		}
+	}
+	if col > len(formatted) {
+		col = len(formatted)
+	}
+
+	m.checkpoint()
+	m.setValue(strings.Join(lines, "\n"), row, col)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const defaultWrapWidth = 72
+
+// renderText converts markdown to plain text, dropping formatting and
+// hard-wrapping paragraphs at width columns. Code blocks are kept as they
+// are, and blocks are separated by a blank line.
+func renderText(markdown string, width int) string {
+	src := []byte(markdown)
+	doc := goldmark.New(goldmark.WithExtensions(extension.GFM)).Parser().Parse(text.NewReader(src))
+
+	blocks := textBlocks(doc, src, width)
+	if len(blocks) == 0 {
+		return ""
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// textBlocks renders each block inside n.
+func textBlocks(n ast.Node, src []byte, width int) []string {
+	var blocks []string
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Heading, *ast.Paragraph, *ast.TextBlock:
+			blocks = append(blocks, wrapText(inlineText(c, src), width))
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			var code strings.Builder
+			for i := 0; i < c.Lines().Len(); i++ {
+				line := c.Lines().At(i)
+				code.Write(line.Value(src))
+			}
+			blocks = append(blocks, strings.TrimRight(code.String(), "\n"))
+		case *ast.Blockquote:
+			quoted := strings.Join(textBlocks(c, src, width-2), "\n\n")
+			blocks = append(blocks, prefixLines(quoted, "> ", "> "))
+		case *ast.List:
+			blocks = append(blocks, listText(c, src, width))
+		case *ast.ThematicBreak:
+			blocks = append(blocks, "---")
+		case *east.Table:
+			var rows []string
+			for row := c.FirstChild(); row != nil; row = row.NextSibling() {
+				var cells []string
+				for cell := row.FirstChild(); cell != nil; cell = cell.NextSibling() {
+					cells = append(cells, inlineText(cell, src))
+				}
+				rows = append(rows, strings.Join(cells, " | "))
+			}
+			blocks = append(blocks, strings.Join(rows, "\n"))
+		case *ast.HTMLBlock:
+		default:
+			blocks = append(blocks, textBlocks(c, src, width)...)
+		}
+	}
+	return blocks
+}
+
+// listText renders a list with its items' markers, indenting everything
+// after an item's first line to line up under its text.
+func listText(list *ast.List, src []byte, width int) string {
+	sep := "\n\n"
+	if list.IsTight {
+		sep = "\n"
+	}
+
+	var items []string
+	number := list.Start
+	for item := list.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := "- "
+		if list.IsOrdered() {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		indent := strings.Repeat(" ", len(marker))
+		body := strings.Join(textBlocks(item, src, width-len(marker)), sep)
+		items = append(items, prefixLines(body, marker, indent))
+	}
+	return strings.Join(items, sep)
+}
+
+// inlineText returns the text of n's inline content without any markup.
+// Links are reduced to their text, and autolinks to their URL.
+func inlineText(n ast.Node, src []byte) string {
+	var b strings.Builder
+	for c := n.FirstChild(); c != nil; c = c.NextSibling() {
+		switch c := c.(type) {
+		case *ast.Text:
+			b.Write(c.Segment.Value(src))
+			if c.HardLineBreak() {
+				b.WriteString("\n")
+			} else if c.SoftLineBreak() {
+				b.WriteString(" ")
+			}
+		case *ast.String:
+			b.Write(c.Value)
+		case *ast.AutoLink:
+			b.Write(c.URL(src))
+		case *ast.RawHTML:
+		case *east.TaskCheckBox:
+			if c.IsChecked {
+				b.WriteString("[x] ")
+			} else {
+				b.WriteString("[ ] ")
+			}
+		default:
+			b.WriteString(inlineText(c, src))
+		}
+	}
+	return b.String()
+}
+
+// wrapText wraps s at width columns, breaking only between words. Line
+// breaks already in s are kept, and a width of zero or less disables
+// wrapping.
+func wrapText(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		current := ""
+		for _, word := range strings.Fields(line) {
+			switch {
+			case current == "":
+				current = word
+			case len([]rune(current))+1+len([]rune(word)) > width:
+				lines = append(lines, current)
+				current = word
+			default:
+				current += " " + word
+			}
+		}
+		lines = append(lines, current)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// prefixLines puts first before the first line of s and rest before the
+// others. Blank lines only get the prefix trimmed of trailing spaces.
+func prefixLines(s, first, rest string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			prefix = strings.TrimRight(prefix, " ")
+		}
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
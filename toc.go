@@ -0,0 +1,124 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// heading is a markdown ATX heading found in the document.
+type heading struct {
+	level int
+	text  string
+	line  int
+}
+
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*[a-zA-Z]")
+
+// parseHeadings returns the headings in s up to maxLevel, skipping anything
+// inside fenced code blocks.
+func parseHeadings(s string, maxLevel int) []heading {
+	var (
+		headings []heading
+		fence    codeFence
+	)
+
+	for i, line := range strings.Split(s, "\n") {
+		if fence.next(line) != outsideFence {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+
+		if !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+		text := strings.TrimSpace(trimmed[level:])
+		if level > maxLevel || text == "" || !strings.HasPrefix(trimmed[level:], " ") {
+			continue
+		}
+
+		headings = append(headings, heading{level: level, text: text, line: i})
+	}
+
+	return headings
+}
+
+func (m *model) openTOC() {
+	m.headings = parseHeadings(m.input.Value(), 3)
+	m.tocCursor = 0
+
+	// Start on the heading the cursor is currently under.
+	for i, h := range m.headings {
+		if h.line <= m.input.Line() {
+			m.tocCursor = i
+		}
+	}
+
+	m.mode = tocMode
+}
+
+func (m model) updateTOC(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch {
+	case msg.String() == "esc", key.Matches(msg, m.keymap.toc):
+		m.mode = editMode
+		return m, m.input.Focus()
+	case msg.String() == "up", msg.String() == "k":
+		if m.tocCursor > 0 {
+			m.tocCursor--
+		}
+	case msg.String() == "down", msg.String() == "j":
+		if m.tocCursor < len(m.headings)-1 {
+			m.tocCursor++
+		}
+	case msg.String() == "enter":
+		m.mode = editMode
+		if len(m.headings) == 0 {
+			return m, m.input.Focus()
+		}
+		return m, m.jumpToHeading(m.headings[m.tocCursor])
+	}
+
+	return m, nil
+}
+
+// jumpToHeading moves the editor cursor to h and scrolls the preview to
+// where it was rendered.
+func (m *model) jumpToHeading(h heading) tea.Cmd {
+	moveCursor(&m.input, h.line, 0)
+	cmd := m.scrollToCursor()
+
+	m.renderPreview()
+	for i, line := range strings.Split(m.previewContent, "\n") {
+		if strings.Contains(ansiEscape.ReplaceAllString(line, ""), h.text) {
+			m.viewport.SetYOffset(i)
+			return cmd
+		}
+	}
+
+	m.syncPreviewScroll()
+	return cmd
+}
+
+func (m model) tocView() string {
+	b := strings.Builder{}
+	b.WriteString("Table of contents\n\n")
+
+	if len(m.headings) == 0 {
+		b.WriteString("  No headings\n")
+	}
+	for i, h := range m.headings {
+		entry := strings.Repeat("  ", h.level-1) + h.text
+		if i == m.tocCursor {
+			b.WriteString(menuSelectedStyle.Render("> "+entry) + "\n")
+		} else {
+			b.WriteString("  " + entry + "\n")
+		}
+	}
+
+	b.WriteString("\nenter jump • esc close")
+	return menuStyle.Render(b.String())
+}
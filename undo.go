@@ -0,0 +1,80 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	// maxUndo bounds how many snapshots are kept in each direction.
+	maxUndo = 100
+
+	// undoDebounce is how long the editor has to sit idle before the next
+	// edit starts a new undo step.
+	undoDebounce = time.Second
+)
+
+// snapshot is the editor contents and cursor position at a point in time.
+type snapshot struct {
+	value    string
+	row, col int
+}
+
+func (m model) snapshot() snapshot {
+	return snapshot{
+		value: m.input.Value(),
+		row:   m.input.Line(),
+		col:   cursorColumn(m.input),
+	}
+}
+
+func (m *model) restore(s snapshot) {
+	m.setValue(s.value, s.row, s.col)
+}
+
+// recordEdit notes that the buffer changed from before. Edits that follow
+// each other in quick succession share the undo step of the first one.
+func (m *model) recordEdit(before snapshot) {
+	if time.Since(m.lastEdit) > undoDebounce {
+		m.undoStack = pushSnapshot(m.undoStack, before)
+	}
+	m.lastEdit = time.Now()
+	m.redoStack = nil
+}
+
+// checkpoint records the current state as its own undo step, for edits made
+// programmatically rather than typed.
+func (m *model) checkpoint() {
+	m.undoStack = pushSnapshot(m.undoStack, m.snapshot())
+	m.redoStack = nil
+	m.lastEdit = time.Time{}
+}
+
+func (m *model) undo() {
+	if len(m.undoStack) == 0 {
+		return
+	}
+
+	m.redoStack = pushSnapshot(m.redoStack, m.snapshot())
+	m.restore(m.undoStack[len(m.undoStack)-1])
+	m.undoStack = m.undoStack[:len(m.undoStack)-1]
+	m.lastEdit = time.Time{}
+}
+
+func (m *model) redo() {
+	if len(m.redoStack) == 0 {
+		return
+	}
+
+	m.undoStack = pushSnapshot(m.undoStack, m.snapshot())
+	m.restore(m.redoStack[len(m.redoStack)-1])
+	m.redoStack = m.redoStack[:len(m.redoStack)-1]
+	m.lastEdit = time.Time{}
+}
+
+func pushSnapshot(stack []snapshot, s snapshot) []snapshot {
+	stack = append(stack, s)
+	if len(stack) > maxUndo {
+		stack = stack[len(stack)-maxUndo:]
+	}
+	return stack
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// version and commit identify the build. Release builds set them with
+//
+//	go build -ldflags "-X main.version=v2.1.0 -X main.commit=$(git rev-parse HEAD)"
+//
+// and otherwise they're filled in from the build info Go records, where it
+// has them.
+var (
+	version = ""
+	commit  = ""
+)
+
+// versionString describes the build for -version, e.g.
+// "markaway v2.1.0 (commit 1a2b3c4d5e6f, go1.19.3)".
+func versionString() string {
+	v, c := version, commit
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if v == "" && info.Main.Version != "(devel)" {
+			v = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" && c == "" {
+				c = s.Value
+			}
+		}
+	}
+	if v == "" {
+		v = "dev"
+	}
+	if c == "" {
+		c = "unknown"
+	}
+	if len(c) > 12 {
+		c = c[:12]
+	}
+
+	return fmt.Sprintf("markaway %s (commit %s, %s)", v, c, runtime.Version())
+}
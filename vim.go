@@ -0,0 +1,160 @@
+package main
+
+import (
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// vimState is the current mode of the optional vim keybindings.
+type vimState int
+
+const (
+	vimNormal vimState = iota
+	vimInsert
+)
+
+func (s vimState) String() string {
+	if s == vimInsert {
+		return "-- INSERT --"
+	}
+	return "-- NORMAL --"
+}
+
+// vimMotions map normal mode keys onto the textarea keys that perform the
+// same movement.
+var vimMotions = map[string]tea.KeyMsg{
+	"h": {Type: tea.KeyLeft},
+	"j": {Type: tea.KeyDown},
+	"k": {Type: tea.KeyUp},
+	"l": {Type: tea.KeyRight},
+	"w": {Type: tea.KeyRight, Alt: true},
+	"b": {Type: tea.KeyLeft, Alt: true},
+	"0": {Type: tea.KeyHome},
+	"$": {Type: tea.KeyEnd},
+}
+
+// updateVim handles a key press with vim bindings enabled. It reports whether
+// the key was consumed; keys it doesn't consume are handled as usual. In
+// normal mode that's only the editor's own bindings and the keys that move
+// the cursor, so nothing else edits the text.
+func (m model) updateVim(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	if m.vimState == vimInsert {
+		if msg.Type == tea.KeyEsc {
+			m.vimState = vimNormal
+			return m, nil, true
+		}
+		return m, nil, false
+	}
+
+	if m.vimCommandActive {
+		m, cmd := m.updateVimCommand(msg)
+		return m, cmd, true
+	}
+
+	if msg.Type == tea.KeyEsc {
+		m.vimPending = ""
+		return m, nil, true
+	}
+	if m.isAppKey(msg) {
+		return m, nil, false
+	}
+	switch msg.Type {
+	case tea.KeyRunes:
+	case tea.KeyUp, tea.KeyDown, tea.KeyLeft, tea.KeyRight, tea.KeyHome, tea.KeyEnd:
+		return m, nil, false
+	default:
+		return m, nil, true
+	}
+
+	var cmd tea.Cmd
+	if !m.input.Focused() {
+		cmd = m.input.Focus()
+	}
+
+	keys := m.vimPending + msg.String()
+	m.vimPending = ""
+
+	if motion, ok := vimMotions[keys]; ok {
+		m.input, _ = m.input.Update(motion)
+		m.syncPreviewScroll()
+		return m, cmd, true
+	}
+
+	switch keys {
+	case "i":
+		m.vimState = vimInsert
+	case "a":
+		m.input, _ = m.input.Update(tea.KeyMsg{Type: tea.KeyRight})
+		m.vimState = vimInsert
+	case "x":
+		if cursorColumn(m.input) < len(currentLine(m.input)) {
+			m.checkpoint()
+			m.input, _ = m.input.Update(tea.KeyMsg{Type: tea.KeyDelete})
+		}
+	case "d":
+		m.vimPending = keys
+	case "dd":
+		m.checkpoint()
+		m.deleteLine(m.input.Line())
+	case ":":
+		m.vimCommandActive = true
+		m.vimCommand = ""
+	}
+
+	return m, cmd, true
+}
+
+// isAppKey reports whether msg triggers one of the editor's own actions.
+func (m model) isAppKey(msg tea.KeyMsg) bool {
+	for _, b := range keyBindings(&m.keymap) {
+		if key.Matches(msg, *b) {
+			return true
+		}
+	}
+	return false
+}
+
+// updateVimCommand edits and runs a : command line.
+func (m model) updateVimCommand(msg tea.KeyMsg) (model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.vimCommandActive = false
+		return m, nil
+	case tea.KeyBackspace:
+		if m.vimCommand == "" {
+			m.vimCommandActive = false
+			return m, nil
+		}
+		m.vimCommand = m.vimCommand[:len(m.vimCommand)-1]
+		return m, nil
+	case tea.KeyRunes:
+		m.vimCommand += msg.String()
+		return m, nil
+	case tea.KeyEnter:
+	default:
+		return m, nil
+	}
+
+	m.vimCommandActive = false
+	switch m.vimCommand {
+	case "w":
+		return m, m.save()
+	case "q":
+		return m, m.quit()
+	case "q!":
+		m.input.Blur()
+		return m, tea.Quit
+	case "wq", "x":
+		return m, m.saveAndQuit()
+	}
+
+	return m, m.setStatus("Not an editor command: "+m.vimCommand, true)
+}
+
+// vimView renders the vim mode or command line for the status bar.
+func (m model) vimView() string {
+	if m.vimCommandActive {
+		return ":" + m.vimCommand
+	}
+	return statusStyle.Render(m.vimState.String())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fileWatchInterval is how often the file is checked for changes made by
+// other programs.
+const fileWatchInterval = 2 * time.Second
+
+type fileWatchMsg struct{}
+
+func watchFile() tea.Cmd {
+	return tea.Tick(fileWatchInterval, func(time.Time) tea.Msg {
+		return fileWatchMsg{}
+	})
+}
+
+// load reads the file into the editor, replacing whatever was there.
+func (m *model) load() error {
+	content, err := loadDocument(m.store, m.filePath)
+	if err != nil {
+		return err
+	}
+
+	m.setContent(content)
+	m.externalChange = false
+	m.recordModTime()
+	return nil
+}
+
+// setContent loads a document, front matter and all, into the editor. It's
+// edited with LF line endings and without a byte order mark, and saved with
+// whichever ones it came with.
+func (m *model) setContent(content string) {
+	m.bom = strings.HasPrefix(content, bom)
+	content = strings.TrimPrefix(content, bom)
+	m.lineEnding = lineEnding(content)
+	content = strings.ReplaceAll(content, crlf, lf)
+
+	fields, body := parseFrontMatter(content)
+	m.metadata = fields
+	if title, ok := fields.Get("title"); ok && title != "" {
+		m.title = title
+	}
+	m.clearMatches()
+	m.input.SetValue(body)
+	m.folds = nil
+	m.savedContent = body
+	m.metadataEdited = false
+	m.dirty = false
+}
+
+// recordModTime remembers the file's current modification time, so that
+// only changes made after it count as external.
+func (m *model) recordModTime() {
+	if modTime, err := m.store.ModTime(m.filePath); err == nil {
+		m.modTime = modTime
+	}
+}
+
+// checkFile reloads the file if it was changed on disk since it was last
+// loaded or saved. If the buffer has edits of its own the user is asked
+// which version to keep instead. While a save is being written the change
+// is our own, so the file isn't checked until it's done.
+func (m *model) checkFile() tea.Cmd {
+	if m.saving {
+		return nil
+	}
+
+	modTime, err := m.store.ModTime(m.filePath)
+	if err != nil || !modTime.After(m.modTime) {
+		return nil
+	}
+
+	if m.dirty {
+		m.externalChange = true
+		return nil
+	}
+
+	if err := m.load(); err != nil {
+		return m.setStatus(err.Error(), true)
+	}
+	return m.setStatus("Reloaded "+m.filePath, false)
+}
+
+func (m model) updateExternalChange(msg tea.KeyMsg) (model, tea.Cmd, bool) {
+	switch msg.String() {
+	case "alt+r":
+		m.checkpoint()
+		if err := m.load(); err != nil {
+			return m, m.setStatus(err.Error(), true), true
+		}
+		return m, m.setStatus("Reloaded "+m.filePath, false), true
+	case "alt+k":
+		m.externalChange = false
+		m.recordModTime()
+		return m, nil, true
+	}
+	return m, nil, false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// noWrapWidth is how wide the textarea is made when soft-wrapping is off, so
+// that lines only wrap once they're too long to be practical to scroll.
+const noWrapWidth = 500
+
+// lineNumberWidth is the width of the textarea's line number gutter.
+var lineNumberWidth = len(fmt.Sprintf("%2v ", 0))
+
+// noWrapEditorView renders the editor scrolled horizontally to keep the
+// cursor in view, in place of soft-wrapping long lines. The textarea is
+// rendered at noWrapWidth without a border and cut down to width here.
+func (m model) noWrapEditorView(width int) string {
+	border := blurredBorderStyle
+	if m.input.Focused() {
+		border = focusedBorderStyle
+	}
+
+	inner := width - border.GetHorizontalFrameSize() - lineNumberWidth
+	if inner < 1 {
+		inner = 1
+	}
+
+	// Scroll just far enough right that the cursor is on screen.
+	line := currentLine(m.input)
+	col := cursorColumn(m.input)
+	if col > len(line) {
+		col = len(line)
+	}
+	offset := lipgloss.Width(string(line[:col])) - inner + 1
+	if offset < 0 {
+		offset = 0
+	}
+
+	lines := strings.Split(m.input.View(), "\n")
+	for i, l := range lines {
+		lines[i] = ansiSlice(l, 0, lineNumberWidth) + ansiSlice(l, lineNumberWidth+offset, inner)
+	}
+	lines = m.foldView(lines, 0, 0)
+	m.rulerView(lines, lineNumberWidth, 0, offset)
+	return border.Render(strings.Join(lines, "\n"))
+}
+
+// ansiSlice returns the cells of s from start to start+width, padded with
+// spaces to width. Escape sequences are kept wherever they fall so styling
+// carries through.
+func ansiSlice(s string, start, width int) string {
+	var (
+		b       strings.Builder
+		pos     int
+		visible int
+		inEsc   bool
+	)
+
+	for _, r := range s {
+		if r == '\x1b' {
+			inEsc = true
+		}
+		if inEsc {
+			b.WriteRune(r)
+			if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+				inEsc = false
+			}
+			continue
+		}
+
+		w := lipgloss.Width(string(r))
+		if pos >= start && pos+w <= start+width {
+			b.WriteRune(r)
+			visible += w
+		}
+		pos += w
+	}
+
+	if visible < width {
+		b.WriteString(strings.Repeat(" ", width-visible))
+	}
+	return b.String()
+}